@@ -1,18 +1,38 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"api/internal/api"
+	"api/internal/bus"
+	"api/internal/config"
+	"api/internal/metrics"
+	"api/internal/middleware"
+	"api/internal/notification"
+	"api/internal/policy"
+	"api/internal/ratelimit"
+	"api/internal/realtime"
+	"api/internal/social"
+	"api/internal/tracing"
+	"api/internal/trash"
+	"api/internal/webhook"
 	db "api/pkg/database"
+	"api/pkg/logging"
 
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
+const workerStopTimeout = 10 * time.Second
+
 type app struct {
 	db *sql.DB
 }
@@ -20,29 +40,236 @@ type app struct {
 func main() {
 	_ = godotenv.Load()
 
-	time.Sleep(10 * time.Second)
-
-	port := ":8080"
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
 
-	Db, err := db.SetupDb()
+	logger, err := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.Level)
 	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
+	slog.SetDefault(logger)
+
+	if len(args) > 0 && args[0] == "migrate" {
+		if err := runMigrate(cfg, args[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(args) > 0 && args[0] == "platform-role" {
+		if err := runPlatformRole(cfg, args[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	logger.Info("loaded configuration", "config", fmt.Sprintf("%+v", *cfg))
+
+	policies, err := policy.Load(cfg.Policies.File)
+	if err != nil {
+		logger.Error("failed to load authorization policies", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("loaded authorization policies", "file", cfg.Policies.File, "policies", len(policies.Policies()))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	Db, err := db.SetupDb(cfg.Database.DB())
+	if err != nil {
+		logger.Error("failed to set up database", "error", err)
+		os.Exit(1)
+	}
 
 	app := app{
 		db: Db,
 	}
 
-	router := api.NewRouter(app.db)
+	migrator, err := db.NewMigrator(app.db)
+	if err != nil {
+		logger.Error("failed to load migrations", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.Database.AutoMigrate {
+		if err := migrateOnStartup(migrator); err != nil {
+			logger.Error("failed to apply migrations", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	eventBus := bus.New()
+
+	hub := realtime.NewHub(app.db)
+	eventBus.Subscribe(hub.Publish)
+
+	inbox := notification.NewInboxChannel(app.db, eventBus)
+	channels := []notification.Channel{inbox}
+	emailConfig := notification.EmailConfig{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password.Value(),
+		From:     cfg.SMTP.From,
+	}
+	if emailConfig.Enabled() {
+		channels = append(channels, notification.NewEmailChannel(emailConfig))
+	}
+
+	reminderConfig := notification.ReminderConfig{
+		Offsets:  cfg.Reminders.Offsets,
+		Interval: cfg.Reminders.Interval,
+	}
+	reminders := notification.NewReminderScheduler(app.db, reminderConfig, notification.NewDispatcher(channels...))
+
+	grantConfig := notification.GrantExpiryConfig{
+		Notice:   cfg.Grants.ExpiryNotice,
+		Interval: cfg.Grants.Interval,
+	}
+	grants := notification.NewGrantExpiryWorker(app.db, grantConfig, notification.NewDispatcher(channels...), eventBus)
+
+	eventBus.Subscribe(notification.NewProducer(app.db, notification.NewDispatcher(inbox)).Handle)
+
+	webhooks := webhook.NewService(app.db)
+	eventBus.Subscribe(webhooks.Handle)
+
+	publisher := social.NewPublisher(app.db, social.NewHTTPConnector())
+
+	// Workers are stopped in this order on shutdown: the ones producing work
+	// first, the realtime listener last.
+	workers := []*worker{
+		startWorker("event reminders", reminders.Run),
+		startWorker("role grant expiry", grants.Run),
+		startWorker("social publisher", publisher.Run),
+		startWorker("webhook deliveries", webhooks.Run),
+		startWorker("trash purge", trash.NewPurger(app.db, cfg.Trash.Retention(), cfg.Trash.PurgeInterval).Run),
+		startWorker("realtime listener", func(ctx context.Context) {
+			if err := hub.Listen(ctx, cfg.Database.DB().ConnectionString()); err != nil {
+				logging.FromContext(ctx).Error("realtime listener stopped", "error", err)
+			}
+		}),
+	}
+
+	corsConfig := middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		MaxAge:           cfg.CORS.MaxAge,
+	}
+	var rateLimitStore ratelimit.Store
+	if cfg.RateLimit.Enabled {
+		if cfg.RateLimit.Store == "postgres" {
+			postgresStore := ratelimit.NewPostgresStore(app.db)
+			workers = append([]*worker{startWorker("rate limit cleanup", postgresStore.Run)}, workers...)
+			rateLimitStore = postgresStore
+		} else {
+			rateLimitStore = ratelimit.NewMemoryStore()
+		}
+	}
+	limiter := middleware.NewRateLimiter(rateLimitStore, map[string]ratelimit.Limit{
+		middleware.RateLimitRead:  ratelimit.Limit(cfg.RateLimit.Read),
+		middleware.RateLimitWrite: ratelimit.Limit(cfg.RateLimit.Write),
+	})
+
+	router := api.NewRouter(app.db, migrator, eventBus, hub, reminders, publisher, corsConfig, limiter, policies)
 
 	r := router.NewRouter()
 
-	fmt.Println("Server is running on port", port)
+	var metricsServer *http.Server
+	if cfg.Metrics.Enabled {
+		metrics.RegisterDB(app.db, cfg.Database.Name)
 
-	err = http.ListenAndServe(port, r)
-	if err != nil {
-		fmt.Println(err)
+		if cfg.Metrics.Token != "" {
+			r.Handle("/metrics", metrics.Handler(cfg.Metrics.Token.Value())).Methods(http.MethodGet)
+		} else {
+			metricsMux := http.NewServeMux()
+			metricsMux.Handle("GET /metrics", metrics.Handler(""))
+			metricsServer = &http.Server{
+				Addr:              cfg.Metrics.Addr,
+				Handler:           metricsMux,
+				ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			}
+		}
+	}
+
+	server := &http.Server{
+		Addr:              cfg.Server.Addr,
+		Handler:           otelhttp.NewHandler(middleware.RealIP(cfg.Server.TrustForwardedFor, middleware.RequestLogger(logger, r)), "http.request"),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+	server.RegisterOnShutdown(hub.Close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 2)
+	go func() {
+		logger.Info("server is running", "addr", cfg.Server.Addr)
+		serverErr <- server.ListenAndServe()
+	}()
+	if metricsServer != nil {
+		go func() {
+			logger.Info("metrics server is running", "addr", cfg.Metrics.Addr)
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+
+	select {
+	case err := <-serverErr:
+		logger.Error("server failed", "error", err)
+		server.Close()
+		if metricsServer != nil {
+			metricsServer.Close()
+		}
+		stopWorkers(workers, workerStopTimeout)
+		app.db.Close()
 		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down, draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("failed to drain requests", "error", err)
 	}
+
+	// The metrics server stays up until the requests are drained so the last
+	// scrapes still see them
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to stop metrics server", "error", err)
+		}
+	}
+
+	stopWorkers(workers, workerStopTimeout)
+
+	if err := app.db.Close(); err != nil {
+		logger.Error("failed to close database", "error", err)
+	}
+
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Error("failed to flush traces", "error", err)
+	}
+
+	logger.Info("server stopped")
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"api/internal/config"
+	"api/internal/permissions"
+	"api/internal/repository"
+	db "api/pkg/database"
+)
+
+const platformRoleUsage = `usage: server [flags] platform-role <command>
+
+commands:
+  grant <user-id> <role>  give a user the superadmin, moderator or auditor role
+  revoke <user-id>        take the platform role of a user away
+  list                    list the users holding a platform role`
+
+// runPlatformRole manages platform roles from the command line, which is how
+// the first superadmin gets appointed.
+func runPlatformRole(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(platformRoleUsage)
+	}
+
+	Db, err := db.SetupDb(cfg.Database.DB())
+	if err != nil {
+		return err
+	}
+	defer Db.Close()
+
+	platformRepository := repository.NewPlatformRepository(db.WithContext(context.Background(), Db))
+
+	switch args[0] {
+	case "grant", "revoke":
+		var userID, role string
+		switch {
+		case args[0] == "grant" && len(args) == 3:
+			userID, role = args[1], args[2]
+			if permissions.GetPlatformRoleWithName(role) == nil {
+				return fmt.Errorf("unknown platform role '%s'", role)
+			}
+		case args[0] == "revoke" && len(args) == 2:
+			userID = args[1]
+		default:
+			return errors.New(platformRoleUsage)
+		}
+
+		found, err := platformRepository.SetPlatformRole(userID, role)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return fmt.Errorf("user '%s' not found", userID)
+		}
+		if role == "" {
+			fmt.Printf("Revoked the platform role of %s\n", userID)
+		} else {
+			fmt.Printf("Granted %s to %s\n", role, userID)
+		}
+	case "list":
+		assignments, err := platformRepository.ListPlatformRoles()
+		if err != nil {
+			return err
+		}
+		for _, assignment := range assignments {
+			fmt.Printf("%-12s %-40s %s\n", assignment.Role, assignment.UserID, assignment.Email)
+		}
+	default:
+		return errors.New(platformRoleUsage)
+	}
+
+	return nil
+}
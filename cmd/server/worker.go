@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"api/pkg/logging"
+)
+
+// worker is a background loop that runs until its context is cancelled.
+type worker struct {
+	name   string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func startWorker(name string, run func(ctx context.Context)) *worker {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = logging.WithLogger(ctx, slog.Default().With("worker", name))
+	w := &worker{
+		name:   name,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(w.done)
+		run(ctx)
+	}()
+
+	return w
+}
+
+// stop cancels the worker and waits for its loop to return.
+func (w *worker) stop(timeout time.Duration) {
+	w.cancel()
+
+	select {
+	case <-w.done:
+		slog.Info("stopped worker", "worker", w.name)
+	case <-time.After(timeout):
+		slog.Warn("timed out stopping worker", "worker", w.name)
+	}
+}
+
+// stopWorkers stops the workers one after another in the given order.
+func stopWorkers(workers []*worker, timeout time.Duration) {
+	for _, w := range workers {
+		w.stop(timeout)
+	}
+}
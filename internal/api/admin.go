@@ -0,0 +1,239 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"api/internal/audit"
+	"api/internal/middleware"
+	"api/internal/models"
+	"api/internal/permissions"
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+const maxAdminPageSize = 100
+
+var errUserNotFound = errors.New("user not found or already in that state")
+
+var clubStatuses = map[string]bool{
+	models.ClubStatusPending:   true,
+	models.ClubStatusActive:    true,
+	models.ClubStatusRejected:  true,
+	models.ClubStatusSuspended: true,
+	models.ClubStatusArchived:  true,
+}
+
+// Lists all clubs for platform staff whatever their status. The status query
+// parameter narrows the list down, e.g. to the clubs waiting for review.
+func (ro *Router) ListAdminClubs(w http.ResponseWriter, r *http.Request) {
+	filter := models.AdminClubFilter{
+		Status: r.URL.Query().Get("status"),
+		Search: r.URL.Query().Get("search"),
+	}
+	if filter.Status != "" && !clubStatuses[filter.Status] {
+		utils.JSONError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	var err error
+	filter.Limit, err = utils.GetQueryInt(r, "limit", 50)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Limit > maxAdminPageSize {
+		filter.Limit = maxAdminPageSize
+	}
+
+	filter.Offset, err = utils.GetQueryInt(r, "offset", 0)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	platformRepository := repository.NewPlatformRepository(ro.conn(r))
+	clubs, err := platformRepository.ListClubs(filter)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, clubs)
+}
+
+// Approves a club waiting for review, which makes it active.
+func (ro *Router) ApproveClub(w http.ResponseWriter, r *http.Request) {
+	var payload models.ClubStatusPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil && !errors.Is(err, io.EOF) {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ro.transitionClub(w, r, models.ClubStatusPending, models.ClubStatusActive, audit.ActionClubApproved, payload.Reason)
+}
+
+// Rejects a club waiting for review. The reason is shown to its owners.
+func (ro *Router) RejectClub(w http.ResponseWriter, r *http.Request) {
+	var payload models.ClubStatusPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(payload.Reason) == "" {
+		utils.JSONError(w, http.StatusBadRequest, "reason is required")
+		return
+	}
+
+	ro.transitionClub(w, r, models.ClubStatusPending, models.ClubStatusRejected, audit.ActionClubRejected, payload.Reason)
+}
+
+func (ro *Router) SuspendClub(w http.ResponseWriter, r *http.Request) {
+	var payload models.ClubStatusPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ro.transitionClub(w, r, models.ClubStatusActive, models.ClubStatusSuspended, audit.ActionClubSuspended, payload.Reason)
+}
+
+func (ro *Router) UnsuspendClub(w http.ResponseWriter, r *http.Request) {
+	ro.transitionClub(w, r, models.ClubStatusSuspended, models.ClubStatusActive, audit.ActionClubUnsuspended, "")
+}
+
+func (ro *Router) VerifyClub(w http.ResponseWriter, r *http.Request) {
+	ro.setClubVerified(w, r, true)
+}
+
+func (ro *Router) UnverifyClub(w http.ResponseWriter, r *http.Request) {
+	ro.setClubVerified(w, r, false)
+}
+
+func (ro *Router) setClubVerified(w http.ResponseWriter, r *http.Request, verified bool) {
+	clubID := r.Header.Get("club-id")
+	if clubID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "club id not found in header")
+		return
+	}
+
+	action := audit.ActionClubVerified
+	if !verified {
+		action = audit.ActionClubUnverified
+	}
+
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		changed, err := tx.Clubs().SetClubVerified(clubID, verified)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return errClubStatus
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     action,
+			TargetType: audit.TargetClub,
+			TargetID:   clubID,
+			Before:     map[string]bool{"verified": !verified},
+			After:      map[string]bool{"verified": verified},
+		})
+	})
+	if errors.Is(err, errClubStatus) {
+		utils.JSONError(w, http.StatusConflict, "club not found, not approved or already in that state")
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"verified": verified})
+}
+
+// Suspended users are turned away by every authenticated endpoint. Platform
+// staff can only be suspended by a superadmin, and nobody can suspend
+// themselves.
+func (ro *Router) SuspendUser(w http.ResponseWriter, r *http.Request) {
+	ro.setUserSuspended(w, r, true)
+}
+
+func (ro *Router) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	ro.setUserSuspended(w, r, false)
+}
+
+func (ro *Router) setUserSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	var payload models.SuspendUserPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil || payload.UserID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if !suspended {
+		payload.Reason = ""
+	}
+
+	actor := auditActor(r)
+	if payload.UserID == actor.UserID {
+		utils.JSONError(w, http.StatusBadRequest, "you cannot suspend yourself")
+		return
+	}
+
+	platformRepository := repository.NewPlatformRepository(ro.conn(r))
+	targetRole, _, err := platformRepository.GetUserAccount(payload.UserID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	staffRole := middleware.PlatformRoleFrom(r.Context())
+	if targetRole != "" && staffRole.Name != permissions.SuperadminRole.Name {
+		utils.JSONError(w, http.StatusForbidden, "Only superadmins can suspend platform staff")
+		return
+	}
+
+	action := audit.ActionUserSuspended
+	if !suspended {
+		action = audit.ActionUserUnsuspended
+	}
+
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		changed, err := repository.NewPlatformRepository(tx.Conn()).SuspendUser(payload.UserID, suspended, payload.Reason)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return errUserNotFound
+		}
+
+		return audit.Record(tx, actor, audit.Change{
+			Action:     action,
+			TargetType: audit.TargetUser,
+			TargetID:   payload.UserID,
+			Before:     models.Suspension{Suspended: !suspended},
+			After:      models.Suspension{Suspended: suspended, Reason: payload.Reason},
+		})
+	})
+	if errors.Is(err, errUserNotFound) {
+		utils.JSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) GetPlatformStats(w http.ResponseWriter, r *http.Request) {
+	platformRepository := repository.NewPlatformRepository(ro.conn(r))
+	stats, err := platformRepository.GetStats()
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, stats)
+}
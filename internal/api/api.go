@@ -3,32 +3,84 @@ package api
 import (
 	"database/sql"
 	"net/http"
+	"slices"
 
+	"api/internal/bus"
 	"api/internal/middleware"
+	"api/internal/notification"
 	"api/internal/permissions"
+	"api/internal/policy"
+	"api/internal/realtime"
+	"api/internal/social"
+	db "api/pkg/database"
 
 	"github.com/gorilla/mux"
 )
 
 type Router struct {
-	db *sql.DB
+	db        *sql.DB
+	bus       *bus.Bus
+	hub       *realtime.Hub
+	reminders *notification.ReminderScheduler
+	social    *social.Publisher
+	migrator  *db.Migrator
+	cors      middleware.CORSConfig
+	limiter   *middleware.RateLimiter
+	policies  *policy.Engine
 }
 
-func NewRouter(db *sql.DB) *Router {
+func NewRouter(sqlDB *sql.DB, migrator *db.Migrator, eventBus *bus.Bus, hub *realtime.Hub, reminders *notification.ReminderScheduler, publisher *social.Publisher, cors middleware.CORSConfig, limiter *middleware.RateLimiter, policies *policy.Engine) *Router {
 	return &Router{
-		db: db,
+		db:        sqlDB,
+		migrator:  migrator,
+		bus:       eventBus,
+		hub:       hub,
+		reminders: reminders,
+		social:    publisher,
+		cors:      cors,
+		limiter:   limiter,
+		policies:  policies,
 	}
 }
 
+// callerRole returns the club role CheckPermission stored in the request
+// context. It never returns nil.
+func callerRole(r *http.Request) *permissions.Role {
+	if role, ok := r.Context().Value("userRole").(*permissions.Role); ok && role != nil {
+		return role
+	}
+	return &permissions.Role{}
+}
+
+// callerHoldsRole reports whether the caller holds the club role roleName
+// themselves. Roles granted through a platform role do not count.
+func callerHoldsRole(r *http.Request, roleName string) bool {
+	roleNames, _ := r.Context().Value("userRoles").([]string)
+	return slices.Contains(roleNames, roleName)
+}
+
+// conn returns a database handle bound to the request context, so queries
+// are cancelled with the request and logged with its request ID.
+func (ro *Router) conn(r *http.Request) db.Conn {
+	return db.WithContext(r.Context(), ro.db)
+}
+
 func (r *Router) NewRouter() *mux.Router {
 	router := mux.NewRouter()
-	authService := middleware.NewAuthorizationService(r.db)
+	authService := middleware.NewAuthorizationService(r.db, r.policies)
 
-	router.Use(middleware.CorsMiddleware)
-	router.HandleFunc("/user", r.CreateUser).Methods(http.MethodPost, http.MethodOptions)
+	router.Use(middleware.RecordRoute)
+	router.Use(middleware.CORS(r.cors, router))
+	router.HandleFunc("/healthz", r.Healthz).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", r.Readyz).Methods(http.MethodGet)
+	router.HandleFunc("/user", r.limiter.LimitClass(middleware.RateLimitWrite)(r.CreateUser)).Methods(http.MethodPost, http.MethodOptions)
 
 	protected := router.PathPrefix("/api").Subrouter()
 	protected.Use(middleware.EnsureValidToken)
+	// Limited before the account is looked up so that throttled clients
+	// cost no query
+	protected.Use(r.limiter.Limit)
+	protected.Use(middleware.CheckAccount(authService))
 
 	protected.HandleFunc("/club-user", r.GetClubWithUserID).Methods(http.MethodGet, http.MethodOptions)
 	protected.HandleFunc("/club-user", middleware.CheckPermission(authService, permissions.AddClubUser)(r.AddClubUser)).Methods(http.MethodPost, http.MethodOptions)
@@ -43,15 +95,83 @@ func (r *Router) NewRouter() *mux.Router {
 	protected.HandleFunc("/events", r.GetAllEvents).Methods(http.MethodGet, http.MethodOptions)
 	protected.HandleFunc("/event", middleware.CheckPermission(authService, permissions.EventUpdatePermission)(r.UpdateEvent)).Methods(http.MethodPut, http.MethodOptions)
 	protected.HandleFunc("/event", middleware.CheckPermission(authService, permissions.EventDeletePermission)(r.DeleteEvent)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/event/cancel", middleware.CheckPermission(authService, permissions.EventUpdatePermission)(r.CancelEvent)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/event/rsvp", r.RSVPEvent).Methods(http.MethodPut, http.MethodOptions)
 
 	// Club endpoints
 	protected.HandleFunc("/club", r.CreateClub).Methods(http.MethodPost, http.MethodOptions)
 	protected.HandleFunc("/club", r.GetClub).Methods(http.MethodGet, http.MethodOptions)
 	protected.HandleFunc("/club", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.UpdateClub)).Methods(http.MethodPut, http.MethodOptions)
 	protected.HandleFunc("/club", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.DeleteClub)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/club/trash", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.ListTrash)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/club/restore", middleware.CheckMembership(authService)(r.RestoreFromTrash)).Methods(http.MethodPost, http.MethodOptions)
+
+	protected.HandleFunc("/club/transfer", middleware.CheckPermission(authService, permissions.ClubReadPermission)(r.GetOwnershipTransfer)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/club/archive", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.ArchiveClub)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/club/unarchive", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.UnarchiveClub)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/club/transfer", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.RequestOwnershipTransfer)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/club/transfer", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.CancelOwnershipTransfer)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/transfer/accept", r.AcceptOwnershipTransfer).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/transfer/decline", r.DeclineOwnershipTransfer).Methods(http.MethodPost, http.MethodOptions)
 
 	protected.HandleFunc("/clubs", r.ListClubs).Methods(http.MethodGet, http.MethodOptions)
 
 	protected.HandleFunc("/user/clubs", r.GetUserClubsWithRoles).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/user/transfers", r.ListOwnershipTransfers).Methods(http.MethodGet, http.MethodOptions)
+
+	// What the caller may do, for the front end to decide which actions to offer
+	protected.HandleFunc("/me/permissions", r.GetMyPermissions).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/me/permissions/clubs", r.ListMyPermissions).Methods(http.MethodGet, http.MethodOptions)
+
+	// Feed post endpoints
+	protected.HandleFunc("/post", middleware.CheckPermission(authService, permissions.ClubWritePermission)(r.CreatePost)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/post", middleware.CheckPermission(authService, permissions.ClubDeletePermission)(r.DeletePost)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/posts", r.GetAllPosts).Methods(http.MethodGet, http.MethodOptions)
+
+	// Notification endpoints
+	protected.HandleFunc("/notifications", r.ListNotifications).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/notifications/unread-count", r.GetUnreadNotificationCount).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/notifications/read", r.MarkNotificationsRead).Methods(http.MethodPut, http.MethodOptions)
+	protected.HandleFunc("/notification/read", r.MarkNotificationRead).Methods(http.MethodPut, http.MethodOptions)
+
+	// Webhook endpoints
+	protected.HandleFunc("/webhook", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.CreateWebhook)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/webhook", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.UpdateWebhook)).Methods(http.MethodPut, http.MethodOptions)
+	protected.HandleFunc("/webhook", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.DeleteWebhook)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/webhooks", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.ListWebhooks)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/webhook/deliveries", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.ListWebhookDeliveries)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/webhook/redeliver", middleware.CheckPermission(authService, permissions.ClubUpdatePermission)(r.RedeliverWebhook)).Methods(http.MethodPost, http.MethodOptions)
+
+	// Social media endpoints
+	protected.HandleFunc("/social/account", middleware.CheckPermission(authService, permissions.SocialMediaWritePermission)(r.CreateSocialAccount)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/social/account", middleware.CheckPermission(authService, permissions.SocialMediaDeletePermission)(r.DeleteSocialAccount)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/social/accounts", middleware.CheckPermission(authService, permissions.SocialMediaReadPermission)(r.ListSocialAccounts)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/social/post", middleware.CheckPermission(authService, permissions.SocialMediaWritePermission)(r.CreateSocialPost)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/social/post", middleware.CheckPermission(authService, permissions.SocialMediaReadPermission)(r.GetSocialPost)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/social/post", middleware.CheckPermission(authService, permissions.SocialMediaUpdatePermission)(r.UpdateSocialPost)).Methods(http.MethodPut, http.MethodOptions)
+	protected.HandleFunc("/social/post", middleware.CheckPermission(authService, permissions.SocialMediaDeletePermission)(r.DeleteSocialPost)).Methods(http.MethodDelete, http.MethodOptions)
+	protected.HandleFunc("/social/post/schedule", middleware.CheckPermission(authService, permissions.SocialMediaUpdatePermission)(r.ScheduleSocialPost)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/social/post/from-event", middleware.CheckPermission(authService, permissions.SocialMediaWritePermission)(r.CreateSocialPostFromEvent)).Methods(http.MethodPost, http.MethodOptions)
+	protected.HandleFunc("/social/posts", middleware.CheckPermission(authService, permissions.SocialMediaReadPermission)(r.ListSocialPosts)).Methods(http.MethodGet, http.MethodOptions)
+
+	// Audit log endpoints
+	protected.HandleFunc("/audit", middleware.CheckPermission(authService, permissions.AuditReadPermission)(r.ListAuditLog)).Methods(http.MethodGet, http.MethodOptions)
+	protected.HandleFunc("/audit/export", middleware.CheckPermission(authService, permissions.AuditReadPermission)(r.ExportAuditLog)).Methods(http.MethodGet, http.MethodOptions)
+
+	protected.HandleFunc("/stream", r.Stream).Methods(http.MethodGet, http.MethodOptions)
+
+	// Platform administration endpoints, across all clubs
+	admin := protected.PathPrefix("/admin").Subrouter()
+	admin.HandleFunc("/clubs", middleware.RequirePlatformPermission(permissions.PlatformClubsReadPermission)(r.ListAdminClubs)).Methods(http.MethodGet, http.MethodOptions)
+	admin.HandleFunc("/club/approve", middleware.RequirePlatformPermission(permissions.PlatformClubsReviewPermission)(r.ApproveClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/club/reject", middleware.RequirePlatformPermission(permissions.PlatformClubsReviewPermission)(r.RejectClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/club/verify", middleware.RequirePlatformPermission(permissions.PlatformClubsVerifyPermission)(r.VerifyClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/club/unverify", middleware.RequirePlatformPermission(permissions.PlatformClubsVerifyPermission)(r.UnverifyClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/club/suspend", middleware.RequirePlatformPermission(permissions.PlatformClubsSuspendPermission)(r.SuspendClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/club/unsuspend", middleware.RequirePlatformPermission(permissions.PlatformClubsSuspendPermission)(r.UnsuspendClub)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/user/suspend", middleware.RequirePlatformPermission(permissions.PlatformUsersSuspendPermission)(r.SuspendUser)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/user/unsuspend", middleware.RequirePlatformPermission(permissions.PlatformUsersSuspendPermission)(r.UnsuspendUser)).Methods(http.MethodPost, http.MethodOptions)
+	admin.HandleFunc("/stats", middleware.RequirePlatformPermission(permissions.PlatformStatsReadPermission)(r.GetPlatformStats)).Methods(http.MethodGet, http.MethodOptions)
+
 	return router
 }
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"api/internal/audit"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/logging"
+	"api/pkg/utils"
+)
+
+const (
+	maxAuditPageSize  = 200
+	auditExportBatch  = 500
+	maxAuditExportRow = 50000
+
+	// Set on exports cut off at maxAuditExportRow entries
+	auditTruncatedHeader = "X-Export-Truncated"
+)
+
+// inTx runs fn in a unit of work bound to the request context.
+func (ro *Router) inTx(r *http.Request, fn func(tx *repository.UnitOfWork) error) error {
+	return repository.RunInTx(r.Context(), ro.db, fn)
+}
+
+// auditActor describes the caller of r for the audit log.
+func auditActor(r *http.Request) audit.Actor {
+	userID, _ := r.Context().Value("userId").(string)
+	if userID == "" {
+		// Routes without a club permission check only carry the token
+		if claims, ok := utils.GetTokenClaims(r); ok {
+			userID, _ = utils.GetUserIDFromClaims(claims)
+		}
+	}
+	return audit.Actor{
+		UserID:    userID,
+		IP:        utils.ClientIP(r),
+		RequestID: logging.RequestIDFromContext(r.Context()),
+	}
+}
+
+func parseAuditFilter(r *http.Request) (models.AuditFilter, error) {
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		Action:      query.Get("action"),
+		ActorUserID: query.Get("actor"),
+		TargetType:  query.Get("target_type"),
+		TargetID:    query.Get("target_id"),
+	}
+
+	for key, dst := range map[string]**time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value := query.Get(key)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return filter, fmt.Errorf("invalid %s query parameter, expected RFC 3339", key)
+		}
+		*dst = &t
+	}
+
+	return filter, nil
+}
+
+func (ro *Router) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Limit, err = utils.GetQueryInt(r, "limit", 50)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Limit > maxAuditPageSize {
+		filter.Limit = maxAuditPageSize
+	}
+
+	filter.Offset, err = utils.GetQueryInt(r, "offset", 0)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	auditRepository := repository.NewAuditRepository(ro.conn(r))
+	entries, err := auditRepository.ListAuditEntries(clubID, filter)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, entries)
+}
+
+// ExportAuditLog streams every entry matching the filters as CSV or as
+// newline delimited JSON, newest first. Exports stop after maxAuditExportRow
+// entries, in which case the X-Export-Truncated header is set and clients
+// narrow the export down with since and until.
+func (ro *Router) ExportAuditLog(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "json" {
+		utils.JSONError(w, http.StatusBadRequest, "format must be csv or json")
+		return
+	}
+
+	auditRepository := repository.NewAuditRepository(ro.conn(r))
+	count, err := auditRepository.CountAuditEntries(clubID, filter, maxAuditExportRow+1)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if count > maxAuditExportRow {
+		w.Header().Set(auditTruncatedHeader, "true")
+	}
+
+	filename := fmt.Sprintf("audit-%s-%s", clubID, time.Now().UTC().Format("20060102T150405Z"))
+	var writeEntry func(entry models.AuditEntry) error
+	var flush func() error
+
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.csv"`)
+
+		csvWriter := csv.NewWriter(w)
+		csvWriter.Write([]string{"id", "created_at", "club_id", "actor_user_id", "action", "target_type", "target_id", "before", "after", "changes", "ip", "request_id"})
+		writeEntry = func(entry models.AuditEntry) error {
+			return csvWriter.Write([]string{
+				entry.ID,
+				entry.CreatedAt,
+				entry.ClubID,
+				entry.ActorUserID,
+				entry.Action,
+				entry.TargetType,
+				entry.TargetID,
+				string(entry.Before),
+				string(entry.After),
+				string(entry.Changes),
+				entry.IP,
+				entry.RequestID,
+			})
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`.ndjson"`)
+
+		encoder := json.NewEncoder(w)
+		writeEntry = func(entry models.AuditEntry) error {
+			return encoder.Encode(entry)
+		}
+		flush = func() error { return nil }
+	}
+
+	// Entries are paged by their position rather than by offset, entries
+	// logged during the export would otherwise shift the pages
+	for exported := 0; exported < maxAuditExportRow; {
+		filter.Limit = min(auditExportBatch, maxAuditExportRow-exported)
+		entries, err := auditRepository.ListAuditEntries(clubID, filter)
+		if err != nil && exported == 0 {
+			utils.JSONError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err != nil {
+			// The status line is already sent, the truncated file is all the
+			// client gets
+			logging.FromContext(r.Context()).Error("failed to export audit log", "exported", exported, "error", err)
+			return
+		}
+
+		for _, entry := range entries {
+			if err := writeEntry(entry); err != nil {
+				return
+			}
+		}
+		if err := flush(); err != nil {
+			return
+		}
+
+		if len(entries) < filter.Limit {
+			return
+		}
+		exported += len(entries)
+		last := entries[len(entries)-1]
+		filter.AfterCreatedAt, filter.AfterID = last.CreatedAt, last.ID
+	}
+}
@@ -1,14 +1,72 @@
 package api
 
 import (
+	"api/internal/audit"
+	"api/internal/bus"
 	"api/internal/models"
 	"api/internal/permissions"
 	"api/internal/repository"
 	"api/pkg/utils"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 )
 
+var (
+	errRoleHeld    = errors.New("user already holds this role")
+	errRoleNotHeld = errors.New("user does not hold this role")
+)
+
+// memberRoles returns the combined role of a member holding the roles named
+// names. Unknown names grant no permissions, so they rank as a plain member.
+func memberRoles(names []string) *permissions.Role {
+	roles := make([]*permissions.Role, 0, len(names))
+	for _, name := range names {
+		if role := permissions.GetRoleWithRoleName(name); role != nil {
+			roles = append(roles, role)
+		} else {
+			roles = append(roles, &permissions.MemberRole)
+		}
+	}
+	if role := permissions.Combine(roles...); role != nil {
+		return role
+	}
+	return &permissions.MemberRole
+}
+
+// roleSet is the audit state of a member holding roles, nil for users who
+// are not a member.
+func roleSet(roles []string) *models.ClubMemberChange {
+	if len(roles) == 0 {
+		return nil
+	}
+	return &models.ClubMemberChange{Roles: roles}
+}
+
+// validateGrant checks the period role is granted for.
+func validateGrant(role *permissions.Role, grant models.RoleGrant) error {
+	if grant.ValidFrom == nil && grant.ValidUntil == nil {
+		return nil
+	}
+	if role.Name == permissions.OwnerRole.Name {
+		return errors.New("ownership cannot be time-limited")
+	}
+	if grant.ValidUntil == nil {
+		return nil
+	}
+	if !grant.ValidUntil.After(time.Now()) {
+		return errors.New("valid_until must be in the future")
+	}
+	if grant.ValidFrom != nil && !grant.ValidUntil.After(*grant.ValidFrom) {
+		return errors.New("valid_until must be after valid_from")
+	}
+	return nil
+}
+
 func (ro *Router) GetClubWithUserID(w http.ResponseWriter, r *http.Request) {
 	claims, ok := utils.GetTokenClaims(r)
 	if !ok {
@@ -22,7 +80,7 @@ func (ro *Router) GetClubWithUserID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
 
 	response, err := clubUserRepository.GetClubsWithUserID(userID)
 	if err != nil {
@@ -47,16 +105,18 @@ func (ro *Router) AddClubUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !(payload.Role == permissions.AdminRole.Name ||
-		payload.Role == permissions.ClubAdminRole.Name ||
-		payload.Role == permissions.MailAdminRole.Name ||
-		payload.Role == permissions.SocialAdminRole.Name) {
+	role := permissions.GetRoleWithRoleName(payload.Role)
+	if role == nil {
 		utils.JSONError(w, http.StatusBadRequest, "invalid role")
 		return
 	}
+	if err := validateGrant(role, payload.RoleGrant); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
-	userRepository := repository.NewUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
+	userRepository := repository.NewUserRepository(ro.conn(r))
 
 	// Get user by email
 	user, err := userRepository.GetUserByEmail(payload.Email)
@@ -65,15 +125,76 @@ func (ro *Router) AddClubUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	success, err := clubUserRepository.CreateClubRole(clubID, user.UserID, payload.Role)
+	// Members who already hold roles get one more
+	previousRoles, err := clubUserRepository.GetUserRoles(clubID, user.UserID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var target *permissions.Role
+	if len(previousRoles) > 0 {
+		target = memberRoles(previousRoles)
+	}
+	if err := permissions.CanManage(callerRole(r), target, role); err != nil {
+		utils.JSONError(w, http.StatusForbidden, err.Error())
+		return
+	}
+
+	actorID, _ := r.Context().Value("userId").(string)
+	grant := payload.RoleGrant
+	grant.GrantedBy = actorID
+	change := models.ClubMemberChange{
+		Role:       payload.Role,
+		Roles:      append(slices.Clone(previousRoles), payload.Role),
+		ValidFrom:  grant.ValidFrom,
+		ValidUntil: grant.ValidUntil,
+	}
+
+	action, topic := audit.ActionMemberAdded, bus.ClubMemberAdded
+	if len(previousRoles) > 0 {
+		action, topic = audit.ActionMemberRoleGranted, bus.ClubMemberRoleChanged
+	}
+
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		created, err := tx.ClubUsers().CreateClubRole(clubID, user.UserID, payload.Role, grant)
+		if err != nil {
+			return err
+		}
+		if !created {
+			return errRoleHeld
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     action,
+			TargetType: audit.TargetMember,
+			TargetID:   user.UserID,
+			Before:     roleSet(previousRoles),
+			After:      change,
+		})
+	})
+	if errors.Is(err, errRoleHeld) {
+		utils.JSONError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": success})
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   topic,
+		ClubID:  clubID,
+		ActorID: actorID,
+		UserID:  user.UserID,
+		Data:    change,
+	})
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
 }
 
+// RemoveClubUser revokes one role from a member when the payload names it and
+// removes the member from the club otherwise.
 func (ro *Router) RemoveClubUser(w http.ResponseWriter, r *http.Request) {
 	clubID := r.Header.Get("club-id")
 	if clubID == "" {
@@ -87,8 +208,8 @@ func (ro *Router) RemoveClubUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
-	userRepository := repository.NewUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
+	userRepository := repository.NewUserRepository(ro.conn(r))
 
 	// Get user by email
 	user, err := userRepository.GetUserByID(payload.UserID)
@@ -98,23 +219,91 @@ func (ro *Router) RemoveClubUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userId := r.Context().Value("userId")
-	role := r.Context().Value("userRole")
 
-	if user.UserID == userId && role == permissions.OwnerRole.Name {
-		utils.JSONError(w, http.StatusInternalServerError, "Owner cannot delete himself")
+	previousRoles, err := clubUserRepository.GetUserRoles(clubID, user.UserID)
+	if err != nil {
+		utils.JSONError(w, http.StatusNotFound, "user is not a member of this club")
+		return
+	}
+	if err := permissions.CanManage(callerRole(r), memberRoles(previousRoles), nil); err != nil {
+		utils.JSONError(w, http.StatusForbidden, err.Error())
 		return
 	}
 
-	// Remove user from club
-	err = clubUserRepository.DeleteClubRole(clubID, user.UserID)
-	if err != nil {
+	var remainingRoles []string
+	if payload.Role != "" {
+		if !slices.Contains(previousRoles, payload.Role) {
+			utils.JSONError(w, http.StatusNotFound, errRoleNotHeld.Error())
+			return
+		}
+		remainingRoles = slices.DeleteFunc(slices.Clone(previousRoles), func(role string) bool {
+			return role == payload.Role
+		})
+	}
+
+	// Remove user from club, or only revoke the role
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		change := audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionMemberRemoved,
+			TargetType: audit.TargetMember,
+			TargetID:   user.UserID,
+			Before:     roleSet(previousRoles),
+		}
+
+		if payload.Role == "" {
+			if err := tx.ClubUsers().DeleteClubMember(clubID, user.UserID); err != nil {
+				return err
+			}
+		} else {
+			revoked, err := tx.ClubUsers().DeleteClubRole(clubID, user.UserID, payload.Role)
+			if err != nil {
+				return err
+			}
+			if !revoked {
+				return errRoleNotHeld
+			}
+			change.Action = audit.ActionMemberRoleRevoked
+			change.After = roleSet(remainingRoles)
+		}
+
+		return audit.Record(tx, auditActor(r), change)
+	})
+	switch {
+	case errors.Is(err, errRoleNotHeld):
+		utils.JSONError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, repository.ErrLastOwner):
+		utils.JSONError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	actorID, _ := userId.(string)
+	if len(remainingRoles) == 0 {
+		ro.bus.Publish(r.Context(), bus.Message{
+			Topic:   bus.ClubMemberRemoved,
+			ClubID:  clubID,
+			ActorID: actorID,
+			UserID:  user.UserID,
+		})
+	} else {
+		ro.bus.Publish(r.Context(), bus.Message{
+			Topic:   bus.ClubMemberRoleChanged,
+			ClubID:  clubID,
+			ActorID: actorID,
+			UserID:  user.UserID,
+			Data:    models.ClubMemberChange{PreviousRole: payload.Role, Roles: remainingRoles},
+		})
+	}
+
 	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
 }
 
+// UpdateClubUserRole replaces all roles of a member with the one in the
+// payload.
 func (ro *Router) UpdateClubUserRole(w http.ResponseWriter, r *http.Request) {
 	clubID := r.Header.Get("club-id")
 	if clubID == "" {
@@ -128,16 +317,14 @@ func (ro *Router) UpdateClubUserRole(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !(payload.Role == permissions.AdminRole.Name ||
-		payload.Role == permissions.ClubAdminRole.Name ||
-		payload.Role == permissions.MailAdminRole.Name ||
-		payload.Role == permissions.SocialAdminRole.Name) {
+	role := permissions.GetRoleWithRoleName(payload.Role)
+	if role == nil {
 		utils.JSONError(w, http.StatusBadRequest, "invalid role")
 		return
 	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
-	userRepository := repository.NewUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
+	userRepository := repository.NewUserRepository(ro.conn(r))
 
 	// Get user by ID
 	user, err := userRepository.GetUserByID(payload.UserID)
@@ -147,20 +334,57 @@ func (ro *Router) UpdateClubUserRole(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userId := r.Context().Value("userId")
-	role := r.Context().Value("userRole")
 
-	if user.UserID == userId && role == permissions.OwnerRole.Name {
-		utils.JSONError(w, http.StatusInternalServerError, "Owner cannot update himself")
+	previousRoles, err := clubUserRepository.GetUserRoles(clubID, user.UserID)
+	if err != nil {
+		utils.JSONError(w, http.StatusNotFound, "user is not a member of this club")
+		return
+	}
+	if err := permissions.CanManage(callerRole(r), memberRoles(previousRoles), role); err != nil {
+		utils.JSONError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err := validateGrant(role, payload.RoleGrant); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Update user role
-	err = clubUserRepository.UpdateClubRole(clubID, user.UserID, payload.Role)
+	actorID, _ := userId.(string)
+	grant := payload.RoleGrant
+	grant.GrantedBy = actorID
+
+	// Update user role, a role without a period replaces a time-limited one
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.ClubUsers().SetClubRole(clubID, user.UserID, payload.Role, grant); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionMemberRoleChanged,
+			TargetType: audit.TargetMember,
+			TargetID:   user.UserID,
+			Before:     roleSet(previousRoles),
+			After:      models.ClubMemberChange{Role: payload.Role, Roles: []string{payload.Role}, ValidFrom: grant.ValidFrom, ValidUntil: grant.ValidUntil},
+		})
+	})
+	if errors.Is(err, repository.ErrLastOwner) {
+		utils.JSONError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.ClubMemberRoleChanged,
+		ClubID:  clubID,
+		ActorID: actorID,
+		UserID:  user.UserID,
+		Data:    models.ClubMemberChange{Role: payload.Role, PreviousRole: strings.Join(previousRoles, ", "), Roles: []string{payload.Role}},
+	})
+
 	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
 }
 
@@ -177,7 +401,7 @@ func (ro *Router) GetUserClubsWithRoles(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
 
 	clubs, err := clubUserRepository.GetUserClubsWithRoles(userID)
 	if err != nil {
@@ -207,10 +431,10 @@ func (ro *Router) GetClubDetailsWithMembers(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
 
 	// Check if user has access to this club
-	_, err := clubUserRepository.GetUserRole(clubID, userID)
+	_, err := clubUserRepository.GetActiveUserRoles(clubID, userID)
 	if err != nil {
 		utils.JSONError(w, http.StatusForbidden, "access denied")
 		return
@@ -1,8 +1,13 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/metrics"
 	"api/internal/models"
 	"api/internal/permissions"
 	"api/internal/repository"
@@ -34,28 +39,32 @@ func (ro *Router) CreateClub(w http.ResponseWriter, r *http.Request) {
 		Email:       payload.Email,
 	}
 
-	clubRepository := repository.NewClubRepository(ro.db)
-	clubID, err := clubRepository.CreateClub(club)
-	if err != nil {
-		utils.JSONError(w, http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	// Create club role for the creator as admin
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
-	_, err = clubUserRepository.CreateClubRole(clubID, userID, "owner")
+	// The club and its owner are created together, a club without owner
+	// could never be managed
+	var clubID string
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		clubID, err = tx.Clubs().CreateClub(club)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ClubUsers().CreateClubRole(clubID, userID, permissions.OwnerRole.Name, models.RoleGrant{})
+		return err
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	metrics.ClubsCreated.Inc()
 	utils.JSONResponse(w, http.StatusCreated, map[string]string{"id": clubID})
 }
 
 func (ro *Router) GetClub(w http.ResponseWriter, r *http.Request) {
 	clubID := r.Header.Get("club-id")
 
-	clubRepository := repository.NewClubRepository(ro.db)
+	clubRepository := repository.NewClubRepository(ro.conn(r))
 	club, err := clubRepository.GetClubByID(clubID)
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
@@ -86,8 +95,27 @@ func (ro *Router) UpdateClub(w http.ResponseWriter, r *http.Request) {
 		Email:       payload.Email,
 	}
 
-	clubRepository := repository.NewClubRepository(ro.db)
-	err := clubRepository.UpdateClub(club)
+	clubRepository := repository.NewClubRepository(ro.conn(r))
+	before, err := clubRepository.GetClubByID(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Clubs().UpdateClub(club); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionClubUpdated,
+			TargetType: audit.TargetClub,
+			TargetID:   clubID,
+			Before:     before,
+			After:      club,
+		})
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -99,23 +127,31 @@ func (ro *Router) UpdateClub(w http.ResponseWriter, r *http.Request) {
 func (ro *Router) DeleteClub(w http.ResponseWriter, r *http.Request) {
 	clubID := r.Header.Get("club-id")
 
-	clubUserRepository := repository.NewClubUserRepository(ro.db)
-
-	userRole := r.Context().Value("userRole")
-
-	if userRole != permissions.OwnerRole.Name {
+	if callerRole(r).Rank != permissions.OwnerRank {
 		utils.JSONError(w, http.StatusBadRequest, "Only owner can delete club")
 		return
 	}
 
-	err := clubUserRepository.DeleteAllClubRoles(clubID)
+	clubRepository := repository.NewClubRepository(ro.conn(r))
+	before, err := clubRepository.GetClubByID(clubID)
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	clubRepository := repository.NewClubRepository(ro.db)
-	err = clubRepository.DeleteClub(clubID)
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Clubs().DeleteClub(clubID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionClubDeleted,
+			TargetType: audit.TargetClub,
+			TargetID:   clubID,
+			Before:     before,
+		})
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -124,8 +160,79 @@ func (ro *Router) DeleteClub(w http.ResponseWriter, r *http.Request) {
 	utils.JSONResponse(w, http.StatusOK, map[string]string{"message": "club deleted successfully"})
 }
 
+// Archiving is how owners close a club down without deleting it. Archived
+// clubs leave the club list and cannot publish until they are unarchived.
+func (ro *Router) ArchiveClub(w http.ResponseWriter, r *http.Request) {
+	if callerRole(r).Rank != permissions.OwnerRank {
+		utils.JSONError(w, http.StatusForbidden, "Only owner can archive club")
+		return
+	}
+
+	ro.transitionClub(w, r, models.ClubStatusActive, models.ClubStatusArchived, audit.ActionClubArchived, "")
+}
+
+func (ro *Router) UnarchiveClub(w http.ResponseWriter, r *http.Request) {
+	if callerRole(r).Rank != permissions.OwnerRank {
+		utils.JSONError(w, http.StatusForbidden, "Only owner can unarchive club")
+		return
+	}
+
+	ro.transitionClub(w, r, models.ClubStatusArchived, models.ClubStatusActive, audit.ActionClubUnarchived, "")
+}
+
+var errClubStatus = errors.New("club not found")
+
+// transitionClub moves the club in the club-id header from status from to
+// status to, records the change in the audit log and lets the owners know.
+func (ro *Router) transitionClub(w http.ResponseWriter, r *http.Request, from, to, action, reason string) {
+	clubID := r.Header.Get("club-id")
+	if clubID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "club id not found in header")
+		return
+	}
+
+	actor := auditActor(r)
+	change := models.ClubStatusChange{Status: to, PreviousStatus: from, Reason: reason}
+
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		changed, err := tx.Clubs().SetClubStatus(clubID, from, to, reason, actor.UserID)
+		if err != nil {
+			return err
+		}
+		if !changed {
+			return errClubStatus
+		}
+
+		return audit.Record(tx, actor, audit.Change{
+			ClubID:     clubID,
+			Action:     action,
+			TargetType: audit.TargetClub,
+			TargetID:   clubID,
+			Before:     models.ClubStatusChange{Status: from},
+			After:      change,
+		})
+	})
+	if errors.Is(err, errClubStatus) {
+		utils.JSONError(w, http.StatusConflict, fmt.Sprintf("club not found or not %s", from))
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.ClubStatusChanged,
+		ClubID:  clubID,
+		ActorID: actor.UserID,
+		Data:    change,
+	})
+
+	utils.JSONResponse(w, http.StatusOK, change)
+}
+
 func (ro *Router) ListClubs(w http.ResponseWriter, r *http.Request) {
-	clubRepository := repository.NewClubRepository(ro.db)
+	clubRepository := repository.NewClubRepository(ro.conn(r))
 	clubs, err := clubRepository.ListClubs()
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
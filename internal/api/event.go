@@ -1,8 +1,12 @@
 package api
 
 import (
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/metrics"
 	"api/internal/models"
 	"api/internal/repository"
+	"api/pkg/logging"
 	"api/pkg/utils"
 	"encoding/json"
 	"net/http"
@@ -16,9 +20,11 @@ func (ro *Router) CreateEvent(w http.ResponseWriter, r *http.Request) {
 	}
 
 	clubID := r.Header.Get("club-id")
+	actorID, _ := r.Context().Value("userId").(string)
 
 	event := models.Event{
 		ClubID:      clubID,
+		CreatedBy:   actorID,
 		Title:       payload.Title,
 		Description: payload.Description,
 		StartDate:   payload.StartDate,
@@ -27,12 +33,38 @@ func (ro *Router) CreateEvent(w http.ResponseWriter, r *http.Request) {
 		Location:    payload.Location,
 	}
 
-	eventRepository := repository.NewEventRepository(ro.db)
-	newEvent, err := eventRepository.CreateEvent(&event)
+	var newEvent *models.Event
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		newEvent, err = tx.Events().CreateEvent(&event)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     newEvent.ClubID,
+			Action:     audit.ActionEventCreated,
+			TargetType: audit.TargetEvent,
+			TargetID:   newEvent.ID,
+			After:      newEvent,
+		})
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	metrics.EventsCreated.Inc()
+
+	if err := ro.reminders.Schedule(r.Context(), newEvent.ID); err != nil {
+		logging.FromContext(r.Context()).Error("failed to schedule reminders", "event_id", newEvent.ID, "error", err)
+	}
+
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.EventCreated,
+		ClubID:  newEvent.ClubID,
+		ActorID: actorID,
+		Data:    newEvent,
+	})
 
 	utils.JSONResponse(w, http.StatusCreated, newEvent)
 }
@@ -44,7 +76,7 @@ func (ro *Router) GetEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventRepository := repository.NewEventRepository(ro.db)
+	eventRepository := repository.NewEventRepository(ro.conn(r))
 	event, err := eventRepository.GetEventByID(eventID)
 	if err != nil {
 		utils.JSONError(w, http.StatusNotFound, "event not found")
@@ -55,7 +87,7 @@ func (ro *Router) GetEvent(w http.ResponseWriter, r *http.Request) {
 }
 
 func (ro *Router) GetAllEvents(w http.ResponseWriter, r *http.Request) {
-	eventRepository := repository.NewEventRepository(ro.db)
+	eventRepository := repository.NewEventRepository(ro.conn(r))
 	events, err := eventRepository.GetAllEvents()
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
@@ -78,14 +110,48 @@ func (ro *Router) UpdateEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventRepository := repository.NewEventRepository(ro.db)
+	eventRepository := repository.NewEventRepository(ro.conn(r))
+	before, err := eventRepository.GetEventByID(eventID)
+	if err != nil {
+		utils.JSONError(w, http.StatusNotFound, "event not found")
+		return
+	}
+
+	var updatedEvent *models.Event
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		updatedEvent, err = tx.Events().UpdateEvent(eventID, &event)
+		if err != nil {
+			return err
+		}
 
-	updatedEvent, err := eventRepository.UpdateEvent(eventID, &event)
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     updatedEvent.ClubID,
+			Action:     audit.ActionEventUpdated,
+			TargetType: audit.TargetEvent,
+			TargetID:   eventID,
+			Before:     before,
+			After:      updatedEvent,
+		})
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// The start date may have moved, so pending reminders are rebuilt
+	if err := ro.reminders.Schedule(r.Context(), updatedEvent.ID); err != nil {
+		logging.FromContext(r.Context()).Error("failed to reschedule reminders", "event_id", updatedEvent.ID, "error", err)
+	}
+
+	actorID, _ := r.Context().Value("userId").(string)
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.EventUpdated,
+		ClubID:  updatedEvent.ClubID,
+		ActorID: actorID,
+		Data:    updatedEvent,
+	})
+
 	utils.JSONResponse(w, http.StatusOK, updatedEvent)
 }
 
@@ -96,18 +162,139 @@ func (ro *Router) DeleteEvent(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	eventRepository := repository.NewEventRepository(ro.db)
-	_, err := eventRepository.GetEventByID(eventID)
+	eventRepository := repository.NewEventRepository(ro.conn(r))
+	event, err := eventRepository.GetEventByID(eventID)
 	if err != nil {
 		utils.JSONError(w, http.StatusNotFound, "event not found")
 		return
 	}
 
-	err = eventRepository.DeleteEvent(eventID)
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Events().DeleteEvent(eventID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     event.ClubID,
+			Action:     audit.ActionEventDeleted,
+			TargetType: audit.TargetEvent,
+			TargetID:   eventID,
+			Before:     event,
+		})
+	})
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	// The event stays in the trash until it is restored or purged, its
+	// reminders must not go out meanwhile
+	if err := ro.reminders.Cancel(r.Context(), eventID); err != nil {
+		logging.FromContext(r.Context()).Error("failed to cancel reminders", "event_id", eventID, "error", err)
+	}
+
+	actorID, _ := r.Context().Value("userId").(string)
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.EventDeleted,
+		ClubID:  event.ClubID,
+		ActorID: actorID,
+		Data:    event,
+	})
+
 	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
 }
+
+func (ro *Router) CancelEvent(w http.ResponseWriter, r *http.Request) {
+	eventID := r.Header.Get("event-id")
+	if eventID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "event id is required")
+		return
+	}
+
+	eventRepository := repository.NewEventRepository(ro.conn(r))
+	before, err := eventRepository.GetEventByID(eventID)
+	if err != nil {
+		utils.JSONError(w, http.StatusNotFound, "event not found")
+		return
+	}
+
+	var cancelledEvent *models.Event
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		cancelledEvent, err = tx.Events().CancelEvent(eventID)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     cancelledEvent.ClubID,
+			Action:     audit.ActionEventCancelled,
+			TargetType: audit.TargetEvent,
+			TargetID:   eventID,
+			Before:     before,
+			After:      cancelledEvent,
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := ro.reminders.Cancel(r.Context(), eventID); err != nil {
+		logging.FromContext(r.Context()).Error("failed to cancel reminders", "event_id", eventID, "error", err)
+	}
+
+	actorID, _ := r.Context().Value("userId").(string)
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.EventCancelled,
+		ClubID:  cancelledEvent.ClubID,
+		ActorID: actorID,
+		Data:    cancelledEvent,
+	})
+
+	utils.JSONResponse(w, http.StatusOK, cancelledEvent)
+}
+
+func (ro *Router) RSVPEvent(w http.ResponseWriter, r *http.Request) {
+	eventID := r.Header.Get("event-id")
+	if eventID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "event id is required")
+		return
+	}
+
+	var payload models.RSVPEventPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if payload.Situation != models.AttendanceGoing && payload.Situation != models.AttendanceNotGoing {
+		utils.JSONError(w, http.StatusBadRequest, "situation must be going or not_going")
+		return
+	}
+
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	eventRepository := repository.NewEventRepository(ro.conn(r))
+	found, err := eventRepository.SetAttendance(eventID, userID, payload.Situation)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !found {
+		utils.JSONError(w, http.StatusNotFound, "event not found")
+		return
+	}
+
+	metrics.EventRSVPs.WithLabelValues(payload.Situation).Inc()
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"situation": payload.Situation})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"api/pkg/logging"
+	"api/pkg/utils"
+)
+
+const readinessTimeout = 2 * time.Second
+
+type readinessResponse struct {
+	Status            string   `json:"status"`
+	Database          string   `json:"database"`
+	PendingMigrations []string `json:"pending_migrations,omitempty"`
+}
+
+// Liveness probe. It only reports that the process is serving requests.
+func (ro *Router) Healthz(w http.ResponseWriter, r *http.Request) {
+	utils.JSONResponse(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness probe. The instance is ready once the database answers and all
+// migrations are applied.
+func (ro *Router) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	response := readinessResponse{Status: "ok", Database: "ok"}
+
+	// The probe is public, errors are logged rather than returned as they
+	// tell about the internals of the database
+	if err := ro.db.PingContext(ctx); err != nil {
+		logging.FromContext(ctx).Error("readiness check failed to reach the database", "error", err)
+		response.Status = "unavailable"
+		response.Database = "unavailable"
+		utils.JSONResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	pending, err := ro.migrator.Pending(ctx)
+	if err != nil {
+		logging.FromContext(ctx).Error("readiness check failed to list pending migrations", "error", err)
+		response.Status = "unavailable"
+		response.Database = "unavailable"
+		utils.JSONResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	if len(pending) > 0 {
+		for _, migration := range pending {
+			response.PendingMigrations = append(response.PendingMigrations, migration.Name)
+		}
+		response.Status = "unavailable"
+		utils.JSONResponse(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, response)
+}
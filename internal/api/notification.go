@@ -0,0 +1,152 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+const maxNotificationPageSize = 100
+
+func (ro *Router) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	limit, err := utils.GetQueryInt(r, "limit", 50)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 || limit > maxNotificationPageSize {
+		limit = maxNotificationPageSize
+	}
+
+	offset, err := utils.GetQueryInt(r, "offset", 0)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+
+	notificationRepository := repository.NewNotificationRepository(ro.conn(r))
+	notifications, err := notificationRepository.ListNotifications(userID, unreadOnly, limit, offset)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, notifications)
+}
+
+func (ro *Router) GetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	notificationRepository := repository.NewNotificationRepository(ro.conn(r))
+	count, err := notificationRepository.CountUnread(userID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, models.UnreadNotificationCount{Count: count})
+}
+
+func (ro *Router) MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	notificationID := r.Header.Get("notification-id")
+	if notificationID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "notification id is required")
+		return
+	}
+	if !utils.IsUUID(notificationID) {
+		utils.JSONError(w, http.StatusBadRequest, "invalid notification id")
+		return
+	}
+
+	notificationRepository := repository.NewNotificationRepository(ro.conn(r))
+	found, err := notificationRepository.MarkAsRead(userID, notificationID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if !found {
+		utils.JSONError(w, http.StatusNotFound, "notification not found")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// Marks the notifications listed in the body as read. An empty body or an
+// empty id list marks every unread notification of the caller as read.
+func (ro *Router) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	var payload models.MarkNotificationsReadPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil && !errors.Is(err, io.EOF) {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	for _, id := range payload.IDs {
+		if !utils.IsUUID(id) {
+			utils.JSONError(w, http.StatusBadRequest, "invalid notification id")
+			return
+		}
+	}
+
+	notificationRepository := repository.NewNotificationRepository(ro.conn(r))
+	updated, err := notificationRepository.MarkManyAsRead(userID, payload.IDs)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]int64{"updated": updated})
+}
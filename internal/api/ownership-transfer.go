@@ -0,0 +1,315 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/permissions"
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+// transferTTL is how long the user a club is offered to has to accept it.
+const transferTTL = 7 * 24 * time.Hour
+
+var (
+	errTransferNotFound = errors.New("transfer not found")
+	errTransferStale    = errors.New("the transfer was offered by a user who is no longer an owner")
+)
+
+func (ro *Router) RequestOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	// Superadmins rank as owners but only an owner can hand their ownership
+	// over, accepting a transfer from anyone else always fails
+	if !callerHoldsRole(r, permissions.OwnerRole.Name) {
+		utils.JSONError(w, http.StatusForbidden, "Only owners can transfer a club")
+		return
+	}
+
+	var payload models.CreateOwnershipTransferPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	userID, _ := r.Context().Value("userId").(string)
+	if payload.UserID == "" || payload.UserID == userID {
+		utils.JSONError(w, http.StatusBadRequest, "invalid user id")
+		return
+	}
+
+	userRepository := repository.NewUserRepository(ro.conn(r))
+	if _, err := userRepository.GetUserByID(payload.UserID); err != nil {
+		utils.JSONError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	var transfer *models.OwnershipTransfer
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		// A new offer replaces the one still waiting for an answer
+		if _, err := tx.OwnershipTransfers().CancelPendingTransfer(clubID); err != nil {
+			return err
+		}
+
+		var err error
+		transfer, err = tx.OwnershipTransfers().CreateTransfer(models.OwnershipTransfer{
+			ClubID:     clubID,
+			FromUserID: userID,
+			ToUserID:   payload.UserID,
+			StayOwner:  payload.StayOwner,
+		}, transferTTL)
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionTransferRequested,
+			TargetType: audit.TargetMember,
+			TargetID:   payload.UserID,
+			After:      transfer,
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.ClubTransferRequested,
+		ClubID:  clubID,
+		ActorID: userID,
+		UserID:  payload.UserID,
+		Data:    transfer,
+	})
+
+	utils.JSONResponse(w, http.StatusCreated, transfer)
+}
+
+func (ro *Router) GetOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	transferRepository := repository.NewOwnershipTransferRepository(ro.conn(r))
+	transfer, err := transferRepository.GetPendingTransfer(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if transfer == nil {
+		utils.JSONError(w, http.StatusNotFound, errTransferNotFound.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, transfer)
+}
+
+func (ro *Router) CancelOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	if !callerHoldsRole(r, permissions.OwnerRole.Name) {
+		utils.JSONError(w, http.StatusForbidden, "Only owners can transfer a club")
+		return
+	}
+
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		cancelled, err := tx.OwnershipTransfers().CancelPendingTransfer(clubID)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return errTransferNotFound
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionTransferCancelled,
+			TargetType: audit.TargetClub,
+			TargetID:   clubID,
+		})
+	})
+	if errors.Is(err, errTransferNotFound) {
+		utils.JSONError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// Lists the clubs the caller was offered to take over.
+func (ro *Router) ListOwnershipTransfers(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	transferRepository := repository.NewOwnershipTransferRepository(ro.conn(r))
+	transfers, err := transferRepository.ListPendingTransfersForUser(userID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, transfers)
+}
+
+// Makes the caller an owner of the club they were offered. The user who
+// offered it becomes an admin unless they asked to stay owner.
+func (ro *Router) AcceptOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	transferID := r.Header.Get("transfer-id")
+	if transferID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "transfer id is required")
+		return
+	}
+
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	var transfer *models.OwnershipTransfer
+	var previousRoles []string
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		transfer, err = tx.OwnershipTransfers().AnswerTransfer(transferID, userID, models.TransferStatusAccepted)
+		if err != nil {
+			return err
+		}
+		if transfer == nil {
+			return errTransferNotFound
+		}
+
+		clubUsers := tx.ClubUsers()
+		fromRoles, err := clubUsers.GetUserRoles(transfer.ClubID, transfer.FromUserID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if !slices.Contains(fromRoles, permissions.OwnerRole.Name) {
+			return errTransferStale
+		}
+
+		previousRoles, err = clubUsers.GetUserRoles(transfer.ClubID, userID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if err := clubUsers.AddOwner(transfer.ClubID, userID); err != nil {
+			return err
+		}
+		// The previous owner keeps their other roles and becomes an admin
+		if !transfer.StayOwner {
+			if _, err := clubUsers.CreateClubRole(transfer.ClubID, transfer.FromUserID, permissions.AdminRole.Name, models.RoleGrant{}); err != nil {
+				return err
+			}
+			if _, err := clubUsers.DeleteClubRole(transfer.ClubID, transfer.FromUserID, permissions.OwnerRole.Name); err != nil {
+				return err
+			}
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     transfer.ClubID,
+			Action:     audit.ActionTransferAccepted,
+			TargetType: audit.TargetMember,
+			TargetID:   userID,
+			Before:     roleSet(previousRoles),
+			After:      models.ClubMemberChange{Role: permissions.OwnerRole.Name},
+		})
+	})
+	switch {
+	case errors.Is(err, errTransferNotFound):
+		utils.JSONError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, errTransferStale):
+		utils.JSONError(w, http.StatusConflict, err.Error())
+		return
+	case err != nil:
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if len(previousRoles) == 0 {
+		ro.bus.Publish(r.Context(), bus.Message{
+			Topic:   bus.ClubMemberAdded,
+			ClubID:  transfer.ClubID,
+			ActorID: userID,
+			UserID:  userID,
+			Data:    models.ClubMemberChange{Role: permissions.OwnerRole.Name},
+		})
+	} else {
+		ro.bus.Publish(r.Context(), bus.Message{
+			Topic:   bus.ClubMemberRoleChanged,
+			ClubID:  transfer.ClubID,
+			ActorID: userID,
+			UserID:  userID,
+			Data:    models.ClubMemberChange{Role: permissions.OwnerRole.Name},
+		})
+	}
+	if !transfer.StayOwner {
+		ro.bus.Publish(r.Context(), bus.Message{
+			Topic:   bus.ClubMemberRoleChanged,
+			ClubID:  transfer.ClubID,
+			ActorID: userID,
+			UserID:  transfer.FromUserID,
+			Data:    models.ClubMemberChange{Role: permissions.AdminRole.Name, PreviousRole: permissions.OwnerRole.Name},
+		})
+	}
+
+	utils.JSONResponse(w, http.StatusOK, transfer)
+}
+
+func (ro *Router) DeclineOwnershipTransfer(w http.ResponseWriter, r *http.Request) {
+	transferID := r.Header.Get("transfer-id")
+	if transferID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "transfer id is required")
+		return
+	}
+
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	transferRepository := repository.NewOwnershipTransferRepository(ro.conn(r))
+	transfer, err := transferRepository.AnswerTransfer(transferID, userID, models.TransferStatusDeclined)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if transfer == nil {
+		utils.JSONError(w, http.StatusNotFound, errTransferNotFound.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, transfer)
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"api/internal/middleware"
+	"api/internal/models"
+	"api/internal/permissions"
+	"api/internal/policy"
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+// Returns what the caller may do in the club given by the club-id query
+// parameter, so the front end can tell which actions to offer. The response
+// carries an ETag that stays the same until the roles of the caller or the
+// status of the club change.
+func (ro *Router) GetMyPermissions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	// Only the query parameter is read: caches key the response by URL, a
+	// club given in a header would be served the permissions of another club
+	clubID := r.URL.Query().Get("club-id")
+	if clubID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "club id is required")
+		return
+	}
+
+	status, err := repository.NewClubRepository(ro.conn(r)).GetClubStatus(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if status == "" || status == models.ClubStatusDeleted {
+		utils.JSONError(w, http.StatusNotFound, "club not found")
+		return
+	}
+
+	// Users who are not a member get an empty set rather than an error, the
+	// front end then simply offers nothing.
+	roles, err := repository.NewClubUserRepository(ro.conn(r)).GetActiveUserRoles(clubID, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	club := models.ClubPermissions{ClubID: clubID, Status: status, Roles: roles}
+	ro.clubPermissions(&club, userID, middleware.PlatformRoleFrom(r.Context()))
+
+	utils.JSONResponseWithETag(w, r, club)
+}
+
+// Batch variant of GetMyPermissions covering every club the caller is a
+// member of, along with the platform permissions of the caller.
+func (ro *Router) ListMyPermissions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	clubs, err := repository.NewClubUserRepository(ro.conn(r)).ListActiveUserClubRoles(userID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	platformRole := middleware.PlatformRoleFrom(r.Context())
+	for i := range clubs {
+		ro.clubPermissions(&clubs[i], userID, platformRole)
+	}
+
+	result := models.UserPermissions{
+		PlatformPermissions: []models.Permission{},
+		Clubs:               clubs,
+	}
+	if platformRole != nil {
+		result.PlatformRole = platformRole.Name
+		for _, permission := range platformRole.Permissions.List() {
+			result.PlatformPermissions = append(result.PlatformPermissions, models.Permission(permission))
+		}
+	}
+
+	utils.JSONResponseWithETag(w, r, result)
+}
+
+// clubPermissions fills in the permissions userID has in club from its
+// roles and the platform role of the caller, nil for users without one. They
+// are decided by the policies like CheckPermission does, for the club
+// itself rather than one of its resources.
+func (ro *Router) clubPermissions(club *models.ClubPermissions, userID string, platformRole *permissions.PlatformRole) {
+	if club.Roles == nil {
+		club.Roles = []string{}
+	}
+	if platformRole != nil {
+		club.PlatformRole = platformRole.Name
+	}
+
+	club.Permissions = []models.Permission{}
+	role := middleware.ClubRole(club.Roles, platformRole)
+	if role == nil {
+		return
+	}
+
+	granted := ro.policies.Granted(policy.Request{
+		Actor: middleware.Actor(userID, club.Roles, platformRole),
+		Club:  policy.Club{ID: club.ClubID, Status: club.Status},
+		Role:  role,
+	})
+	for _, permission := range granted {
+		club.Permissions = append(club.Permissions, models.Permission(permission))
+	}
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"net/http"
+
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+func (ro *Router) CreatePost(w http.ResponseWriter, r *http.Request) {
+	var payload models.CreatePostPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	clubID := r.Header.Get("club-id")
+	userID, _ := r.Context().Value("userId").(string)
+
+	post := models.Post{
+		ClubID:      clubID,
+		UserID:      userID,
+		Image:       payload.Image,
+		Description: payload.Description,
+	}
+
+	postRepository := repository.NewPostRepository(ro.conn(r))
+	newPost, err := postRepository.CreatePost(&post)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	ro.bus.Publish(r.Context(), bus.Message{
+		Topic:   bus.FeedPostCreated,
+		ClubID:  newPost.ClubID,
+		ActorID: userID,
+		Data:    newPost,
+	})
+
+	utils.JSONResponse(w, http.StatusCreated, newPost)
+}
+
+func (ro *Router) GetAllPosts(w http.ResponseWriter, r *http.Request) {
+	postRepository := repository.NewPostRepository(ro.conn(r))
+	posts, err := postRepository.GetAllPosts()
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, posts)
+}
+
+// Moves a post of the club to its trash.
+func (ro *Router) DeletePost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	postID := r.Header.Get("post-id")
+	if postID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "post id is required")
+		return
+	}
+
+	var deleted bool
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		deleted, err = tx.Posts().DeletePost(clubID, postID)
+		if err != nil || !deleted {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionPostDeleted,
+			TargetType: audit.TargetPost,
+			TargetID:   postID,
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !deleted {
+		utils.JSONError(w, http.StatusNotFound, "post not found")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
@@ -0,0 +1,350 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"api/internal/audit"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/internal/social"
+	"api/pkg/utils"
+)
+
+func (ro *Router) CreateSocialAccount(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	var payload models.CreateSocialAccountPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	connector, ok := ro.social.Connector(payload.Platform)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "unsupported platform, expected one of: "+strings.Join(ro.social.Platforms(), ", "))
+		return
+	}
+
+	if err := connector.Validate(r.Context(), payload.Config); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// The connector credentials in Config are never serialized, so they do
+	// not end up in the audit log
+	var account *models.SocialAccount
+	err := ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		var err error
+		account, err = tx.Social().CreateAccount(models.SocialAccount{
+			ClubID:   clubID,
+			Platform: payload.Platform,
+			Name:     payload.Name,
+			Config:   payload.Config,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionSocialAccountCreated,
+			TargetType: audit.TargetSocialAccount,
+			TargetID:   account.ID,
+			After:      account,
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, account)
+}
+
+func (ro *Router) ListSocialAccounts(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	accounts, err := socialRepository.ListAccounts(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, accounts)
+}
+
+func (ro *Router) DeleteSocialAccount(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	accountID := r.Header.Get("social-account-id")
+	if accountID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "social account id is required")
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	account, err := socialRepository.GetAccount(clubID, accountID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		utils.JSONError(w, http.StatusNotFound, "social account not found")
+		return
+	}
+
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Social().DeleteAccount(clubID, accountID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionSocialAccountDeleted,
+			TargetType: audit.TargetSocialAccount,
+			TargetID:   accountID,
+			Before:     account,
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) CreateSocialPost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	userID, _ := r.Context().Value("userId").(string)
+
+	var payload models.CreateSocialPostPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(payload.Content) == "" {
+		utils.JSONError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	account, err := socialRepository.GetAccount(clubID, payload.AccountID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		utils.JSONError(w, http.StatusNotFound, "social account not found")
+		return
+	}
+
+	post, err := socialRepository.CreatePost(models.SocialPost{
+		ClubID:    clubID,
+		AccountID: account.ID,
+		UserID:    userID,
+		Content:   payload.Content,
+		Image:     payload.Image,
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, post)
+}
+
+// Drafts a post announcing the event given in the event-id header.
+func (ro *Router) CreateSocialPostFromEvent(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	userID, _ := r.Context().Value("userId").(string)
+
+	eventID := r.Header.Get("event-id")
+	if eventID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "event id is required")
+		return
+	}
+
+	var payload models.CreateSocialPostFromEventPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	eventRepository := repository.NewEventRepository(ro.conn(r))
+	event, err := eventRepository.GetEventByID(eventID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && event.ClubID != clubID) {
+		utils.JSONError(w, http.StatusNotFound, "event not found")
+		return
+	}
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	account, err := socialRepository.GetAccount(clubID, payload.AccountID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if account == nil {
+		utils.JSONError(w, http.StatusNotFound, "social account not found")
+		return
+	}
+
+	post, err := socialRepository.CreatePost(models.SocialPost{
+		ClubID:    clubID,
+		AccountID: account.ID,
+		UserID:    userID,
+		EventID:   &event.ID,
+		Content:   social.PostFromEvent(*event),
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusCreated, post)
+}
+
+func (ro *Router) ListSocialPosts(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	status := r.URL.Query().Get("status")
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	posts, err := socialRepository.ListPosts(clubID, status)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, posts)
+}
+
+func (ro *Router) GetSocialPost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	postID := r.Header.Get("social-post-id")
+	if postID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "social post id is required")
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	post, err := socialRepository.GetPost(clubID, postID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if post == nil {
+		utils.JSONError(w, http.StatusNotFound, "social post not found")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, post)
+}
+
+func (ro *Router) UpdateSocialPost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	postID := r.Header.Get("social-post-id")
+	if postID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "social post id is required")
+		return
+	}
+
+	var payload models.UpdateSocialPostPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(payload.Content) == "" {
+		utils.JSONError(w, http.StatusBadRequest, "content is required")
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	updated, err := socialRepository.UpdatePost(clubID, postID, payload.Content, payload.Image)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !updated {
+		utils.JSONError(w, http.StatusConflict, "social post not found or already published")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) ScheduleSocialPost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	postID := r.Header.Get("social-post-id")
+	if postID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "social post id is required")
+		return
+	}
+
+	var payload models.ScheduleSocialPostPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	// An empty scheduled_at publishes the post right away
+	scheduledAt := time.Now()
+	if payload.ScheduledAt != "" {
+		t, err := time.Parse(time.RFC3339, payload.ScheduledAt)
+		if err != nil {
+			utils.JSONError(w, http.StatusBadRequest, "scheduled_at must be an RFC3339 timestamp")
+			return
+		}
+		scheduledAt = t
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	scheduled, err := socialRepository.SchedulePost(clubID, postID, scheduledAt)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if !scheduled {
+		utils.JSONError(w, http.StatusConflict, "social post not found or already published")
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) DeleteSocialPost(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	postID := r.Header.Get("social-post-id")
+	if postID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "social post id is required")
+		return
+	}
+
+	socialRepository := repository.NewSocialRepository(ro.conn(r))
+	post, err := socialRepository.GetPost(clubID, postID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if post == nil {
+		utils.JSONError(w, http.StatusNotFound, "social post not found")
+		return
+	}
+
+	err = socialRepository.DeletePost(clubID, postID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
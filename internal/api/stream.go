@@ -0,0 +1,84 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"api/internal/repository"
+	"api/pkg/utils"
+)
+
+const streamHeartbeatInterval = 25 * time.Second
+
+// Streams domain events of the caller's clubs as Server-Sent Events.
+func (ro *Router) Stream(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "claims not found")
+		return
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "user id not found")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.JSONError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	clubUserRepository := repository.NewClubUserRepository(ro.conn(r))
+	clubs, err := clubUserRepository.GetUserClubsWithRoles(userID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	clubIDs := make([]string, 0, len(clubs))
+	for _, club := range clubs {
+		clubIDs = append(clubIDs, club.ClubID)
+	}
+
+	subscription := ro.hub.Subscribe(userID, clubIDs)
+	defer subscription.Close()
+
+	// Streams outlive the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-subscription.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event := <-subscription.Events():
+			data, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Topic, data)
+		}
+		flusher.Flush()
+	}
+}
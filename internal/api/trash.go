@@ -0,0 +1,157 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"api/internal/audit"
+	"api/internal/middleware"
+	"api/internal/models"
+	"api/internal/permissions"
+	"api/internal/policy"
+	"api/internal/repository"
+	"api/pkg/logging"
+	"api/pkg/utils"
+)
+
+var errNotInTrash = errors.New("item not found in trash")
+
+// restorePermissions is the permission needed to restore each type of item,
+// the same one that is needed to delete it.
+var restorePermissions = map[string]permissions.Permission{
+	models.TrashTypeClub:  permissions.ClubDeletePermission,
+	models.TrashTypeEvent: permissions.EventDeletePermission,
+	models.TrashTypePost:  permissions.ClubDeletePermission,
+}
+
+func (ro *Router) ListTrash(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	trashRepository := repository.NewTrashRepository(ro.conn(r))
+	items, err := trashRepository.ListClubTrash(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, items)
+}
+
+func (ro *Router) RestoreFromTrash(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	var payload models.RestorePayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	permission, ok := restorePermissions[payload.Type]
+	if !ok {
+		utils.JSONError(w, http.StatusBadRequest, "invalid type")
+		return
+	}
+	if payload.Type != models.TrashTypeClub && !utils.IsUUID(payload.ID) {
+		utils.JSONError(w, http.StatusBadRequest, "invalid id")
+		return
+	}
+	resource, err := ro.trashedResource(r, clubID, payload)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if resource == nil && payload.Type != models.TrashTypeClub {
+		utils.JSONError(w, http.StatusNotFound, errNotInTrash.Error())
+		return
+	}
+
+	// Restoring goes through the same policies as deleting, so that members
+	// who could delete an item through a policy can bring it back
+	request, _ := middleware.PolicyRequestFrom(r.Context())
+	request.Resource, request.Permission = resource, permission
+	if decision := ro.policies.Evaluate(request); !decision.Allowed {
+		utils.JSONError(w, http.StatusForbidden, "Forbidden: "+decision.Reason)
+		return
+	}
+	// Deleting a club is reserved to its owner, so is bringing it back
+	if payload.Type == models.TrashTypeClub && request.Role.Rank != permissions.OwnerRank {
+		utils.JSONError(w, http.StatusForbidden, "Only owner can restore club")
+		return
+	}
+
+	var restoredEvent *models.Event
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		change := audit.Change{ClubID: clubID, TargetID: payload.ID}
+
+		switch payload.Type {
+		case models.TrashTypeClub:
+			change.Action, change.TargetType, change.TargetID = audit.ActionClubRestored, audit.TargetClub, clubID
+			restored, err := tx.Clubs().RestoreClub(clubID)
+			if err != nil {
+				return err
+			}
+			if !restored {
+				return errNotInTrash
+			}
+		case models.TrashTypeEvent:
+			change.Action, change.TargetType = audit.ActionEventRestored, audit.TargetEvent
+			event, err := tx.Events().RestoreEvent(clubID, payload.ID)
+			if err != nil {
+				return err
+			}
+			if event == nil {
+				return errNotInTrash
+			}
+			restoredEvent, change.After = event, event
+		case models.TrashTypePost:
+			change.Action, change.TargetType = audit.ActionPostRestored, audit.TargetPost
+			restored, err := tx.Posts().RestorePost(clubID, payload.ID)
+			if err != nil {
+				return err
+			}
+			if !restored {
+				return errNotInTrash
+			}
+		}
+
+		return audit.Record(tx, auditActor(r), change)
+	})
+	switch {
+	case errors.Is(err, errNotInTrash):
+		utils.JSONError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Reminders were left alone while the event was in the trash
+	if restoredEvent != nil {
+		if err := ro.reminders.Schedule(r.Context(), restoredEvent.ID); err != nil {
+			logging.FromContext(r.Context()).Error("failed to reschedule reminders", "event_id", restoredEvent.ID, "error", err)
+		}
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+// trashedResource returns the event or post of the club in the trash that
+// payload names, as a policy resource. It returns nil for the club itself
+// and for items that are not in the trash.
+func (ro *Router) trashedResource(r *http.Request, clubID string, payload models.RestorePayload) (*policy.Resource, error) {
+	switch payload.Type {
+	case models.TrashTypeEvent:
+		event, err := repository.NewEventRepository(ro.conn(r)).GetTrashedEvent(clubID, payload.ID)
+		if err != nil || event == nil {
+			return nil, err
+		}
+		return &policy.Resource{Type: "event", ID: event.ID, Club: event.ClubID, Owner: event.CreatedBy, Status: event.Status}, nil
+	case models.TrashTypePost:
+		post, err := repository.NewPostRepository(ro.conn(r)).GetTrashedPost(clubID, payload.ID)
+		if err != nil || post == nil {
+			return nil, err
+		}
+		return &policy.Resource{Type: "post", ID: post.ID, Club: post.ClubID, Owner: post.UserID}, nil
+	}
+	return nil, nil
+}
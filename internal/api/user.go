@@ -27,7 +27,7 @@ func (ro *Router) CreateUser(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:            utils.GetCurrentTime(),
 	}
 
-	userRepository := repository.NewUserRepository(ro.db)
+	userRepository := repository.NewUserRepository(ro.conn(r))
 	result, err := userRepository.CreateUser(user)
 	if err != nil {
 		utils.JSONError(w, http.StatusInternalServerError, err.Error())
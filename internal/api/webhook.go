@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/repository"
+	"api/internal/webhook"
+	"api/pkg/netguard"
+	"api/pkg/utils"
+)
+
+const maxWebhookDeliveriesPageSize = 100
+
+// validateWebhook refuses URLs pointing at the internal network, otherwise
+// the delivery log would let club admins probe it.
+func validateWebhook(ctx context.Context, rawURL string, topics []string) error {
+	if err := netguard.CheckURL(ctx, rawURL); err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+
+	if len(topics) == 0 {
+		return errors.New("at least one topic is required")
+	}
+	for _, topic := range topics {
+		if !webhook.Topics[bus.Topic(topic)] {
+			return errors.New("invalid topic " + topic)
+		}
+	}
+
+	return nil
+}
+
+// auditWebhook strips the signing secret so it never ends up in the audit log.
+func auditWebhook(hook models.Webhook) models.Webhook {
+	hook.Secret = ""
+	return hook
+}
+
+func (ro *Router) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	var payload models.CreateWebhookPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validateWebhook(r.Context(), payload.URL, payload.Topics); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	secret, err := webhook.GenerateSecret()
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var newWebhook *models.Webhook
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		newWebhook, err = tx.Webhooks().CreateWebhook(models.Webhook{
+			ClubID: clubID,
+			URL:    payload.URL,
+			Secret: secret,
+			Topics: payload.Topics,
+		})
+		if err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionWebhookCreated,
+			TargetType: audit.TargetWebhook,
+			TargetID:   newWebhook.ID,
+			After:      auditWebhook(*newWebhook),
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// The secret is only returned once, on creation
+	utils.JSONResponse(w, http.StatusCreated, newWebhook)
+}
+
+func (ro *Router) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+
+	webhookRepository := repository.NewWebhookRepository(ro.conn(r))
+	webhooks, err := webhookRepository.ListWebhooks(clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, webhooks)
+}
+
+func (ro *Router) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	webhookID := r.Header.Get("webhook-id")
+	if webhookID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "webhook id is required")
+		return
+	}
+
+	var payload models.UpdateWebhookPayload
+	if err := utils.DecodeRequestBody(r, &payload); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validateWebhook(r.Context(), payload.URL, payload.Topics); err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	webhookRepository := repository.NewWebhookRepository(ro.conn(r))
+	existing, err := webhookRepository.GetWebhook(clubID, webhookID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if existing == nil {
+		utils.JSONError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	updated := models.Webhook{
+		ID:     webhookID,
+		ClubID: clubID,
+		URL:    payload.URL,
+		Topics: payload.Topics,
+		Active: payload.Active,
+	}
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Webhooks().UpdateWebhook(updated); err != nil {
+			return err
+		}
+
+		after := *existing
+		after.URL, after.Topics, after.Active = updated.URL, updated.Topics, updated.Active
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionWebhookUpdated,
+			TargetType: audit.TargetWebhook,
+			TargetID:   webhookID,
+			Before:     auditWebhook(*existing),
+			After:      auditWebhook(after),
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	webhookID := r.Header.Get("webhook-id")
+	if webhookID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "webhook id is required")
+		return
+	}
+
+	webhookRepository := repository.NewWebhookRepository(ro.conn(r))
+	existing, err := webhookRepository.GetWebhook(clubID, webhookID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if existing == nil {
+		utils.JSONError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	err = ro.inTx(r, func(tx *repository.UnitOfWork) error {
+		if err := tx.Webhooks().DeleteWebhook(clubID, webhookID); err != nil {
+			return err
+		}
+
+		return audit.Record(tx, auditActor(r), audit.Change{
+			ClubID:     clubID,
+			Action:     audit.ActionWebhookDeleted,
+			TargetType: audit.TargetWebhook,
+			TargetID:   webhookID,
+			Before:     auditWebhook(*existing),
+		})
+	})
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, map[string]bool{"success": true})
+}
+
+func (ro *Router) ListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	webhookID := r.Header.Get("webhook-id")
+	if webhookID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "webhook id is required")
+		return
+	}
+
+	limit, err := utils.GetQueryInt(r, "limit", 50)
+	if err != nil {
+		utils.JSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if limit == 0 || limit > maxWebhookDeliveriesPageSize {
+		limit = maxWebhookDeliveriesPageSize
+	}
+
+	webhookRepository := repository.NewWebhookRepository(ro.conn(r))
+	existing, err := webhookRepository.GetWebhook(clubID, webhookID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if existing == nil {
+		utils.JSONError(w, http.StatusNotFound, "webhook not found")
+		return
+	}
+
+	deliveries, err := webhookRepository.ListDeliveries(webhookID, limit)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusOK, deliveries)
+}
+
+// Queues a copy of an earlier delivery. The original stays in the log.
+func (ro *Router) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	clubID := r.Header.Get("club-id")
+	deliveryID := r.Header.Get("delivery-id")
+	if deliveryID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "delivery id is required")
+		return
+	}
+
+	webhookRepository := repository.NewWebhookRepository(ro.conn(r))
+	delivery, err := webhookRepository.GetDelivery(clubID, deliveryID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if delivery == nil {
+		utils.JSONError(w, http.StatusNotFound, "delivery not found")
+		return
+	}
+
+	newDeliveryID, err := webhookRepository.CreateDelivery(delivery.WebhookID, delivery.Topic, delivery.Payload)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.JSONResponse(w, http.StatusAccepted, map[string]string{"id": newDeliveryID})
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"api/internal/models"
+	"api/internal/repository"
+)
+
+const (
+	ActionMemberAdded          = "member.added"
+	ActionMemberRemoved        = "member.removed"
+	ActionMemberRoleChanged    = "member.role_changed"
+	ActionMemberRoleGranted    = "member.role_granted"
+	ActionMemberRoleRevoked    = "member.role_revoked"
+	ActionMemberGrantExpired   = "member.grant_expired"
+	ActionClubUpdated          = "club.updated"
+	ActionClubDeleted          = "club.deleted"
+	ActionClubRestored         = "club.restored"
+	ActionClubApproved         = "club.approved"
+	ActionClubRejected         = "club.rejected"
+	ActionClubSuspended        = "club.suspended"
+	ActionClubUnsuspended      = "club.unsuspended"
+	ActionClubArchived         = "club.archived"
+	ActionClubUnarchived       = "club.unarchived"
+	ActionClubVerified         = "club.verified"
+	ActionClubUnverified       = "club.unverified"
+	ActionTransferRequested    = "club.transfer_requested"
+	ActionTransferCancelled    = "club.transfer_cancelled"
+	ActionTransferAccepted     = "club.transfer_accepted"
+	ActionEventCreated         = "event.created"
+	ActionEventUpdated         = "event.updated"
+	ActionEventCancelled       = "event.cancelled"
+	ActionEventDeleted         = "event.deleted"
+	ActionEventRestored        = "event.restored"
+	ActionPostDeleted          = "post.deleted"
+	ActionPostRestored         = "post.restored"
+	ActionWebhookCreated       = "webhook.created"
+	ActionWebhookUpdated       = "webhook.updated"
+	ActionWebhookDeleted       = "webhook.deleted"
+	ActionSocialAccountCreated = "social_account.created"
+	ActionSocialAccountDeleted = "social_account.deleted"
+	ActionUserSuspended        = "user.suspended"
+	ActionUserUnsuspended      = "user.unsuspended"
+)
+
+const (
+	TargetMember        = "member"
+	TargetClub          = "club"
+	TargetEvent         = "event"
+	TargetPost          = "post"
+	TargetWebhook       = "webhook"
+	TargetSocialAccount = "social_account"
+	TargetUser          = "user"
+)
+
+// Actor describes who made a change and from where.
+type Actor struct {
+	UserID    string
+	IP        string
+	RequestID string
+}
+
+// Change is a privileged change to one target. Before is nil for created
+// targets and After is nil for deleted ones.
+type Change struct {
+	ClubID     string
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// Record appends change to the audit log. It runs in the unit of work that
+// makes the change, so the change and its entry are committed together.
+func Record(uow *repository.UnitOfWork, actor Actor, change Change) error {
+	before, err := encode(change.Before)
+	if err != nil {
+		return err
+	}
+	after, err := encode(change.After)
+	if err != nil {
+		return err
+	}
+	changes, err := Diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	return uow.Audit().CreateAuditEntry(models.AuditEntry{
+		ClubID:      change.ClubID,
+		ActorUserID: actor.UserID,
+		Action:      change.Action,
+		TargetType:  change.TargetType,
+		TargetID:    change.TargetID,
+		Before:      before,
+		After:       after,
+		Changes:     changes,
+		IP:          actor.IP,
+		RequestID:   actor.RequestID,
+	})
+}
+
+func encode(v any) ([]byte, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Pointer && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+type fieldChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Diff returns the top-level fields that differ between two JSON objects as
+// {"field": {"before": ..., "after": ...}}. It returns nil when nothing
+// changed.
+func Diff(before, after []byte) ([]byte, error) {
+	beforeFields, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]fieldChange{}
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			changes[name] = fieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = fieldChange{After: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+func fields(data []byte) (map[string]any, error) {
+	fields := map[string]any{}
+	if len(data) == 0 {
+		return fields, nil
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
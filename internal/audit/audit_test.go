@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name   string
+		before string
+		after  string
+		// Expected changes as JSON, empty when nothing changed
+		want string
+	}{
+		{"nothing changed", `{"name": "Chess", "verified": true}`, `{"verified": true, "name": "Chess"}`, ""},
+		{"both empty", ``, ``, ""},
+		{"field changed", `{"name": "Chess", "email": "a@example.com"}`, `{"name": "Chess club", "email": "a@example.com"}`,
+			`{"name": {"before": "Chess", "after": "Chess club"}}`},
+		{"field removed", `{"name": "Chess", "email": "a@example.com"}`, `{"name": "Chess"}`,
+			`{"email": {"before": "a@example.com", "after": null}}`},
+		{"field added", `{"name": "Chess"}`, `{"name": "Chess", "verified": false}`,
+			`{"verified": {"before": null, "after": false}}`},
+		{"created", ``, `{"name": "Chess"}`,
+			`{"name": {"before": null, "after": "Chess"}}`},
+		{"deleted", `{"name": "Chess"}`, ``,
+			`{"name": {"before": "Chess", "after": null}}`},
+		{"set to null", `{"location": "Hall"}`, `{"location": null}`,
+			`{"location": {"before": "Hall", "after": null}}`},
+		{"same nested values", `{"roles": ["admin", "owner"], "limits": {"read": 1}}`, `{"roles": ["admin", "owner"], "limits": {"read": 1}}`, ""},
+		{"nested value changed", `{"roles": ["admin", "owner"]}`, `{"roles": ["owner"]}`,
+			`{"roles": {"before": ["admin", "owner"], "after": ["owner"]}}`},
+		{"numbers compare by value", `{"count": 1}`, `{"count": 1.0}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Diff([]byte(tt.before), []byte(tt.after))
+			if err != nil {
+				t.Fatalf("Diff() = %v", err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("Diff() = %s, want nil", got)
+				}
+				return
+			}
+
+			var gotChanges, wantChanges any
+			if err := json.Unmarshal(got, &gotChanges); err != nil {
+				t.Fatalf("Diff() = %s, not JSON: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantChanges); err != nil {
+				t.Fatalf("invalid want: %v", err)
+			}
+			if !reflect.DeepEqual(gotChanges, wantChanges) {
+				t.Errorf("Diff() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffInvalidJSON(t *testing.T) {
+	for _, tt := range []struct{ before, after string }{
+		{`{"name": `, `{}`},
+		{`{}`, `["not", "an", "object"]`},
+	} {
+		if _, err := Diff([]byte(tt.before), []byte(tt.after)); err == nil {
+			t.Errorf("Diff(%s, %s) = nil error, want one", tt.before, tt.after)
+		}
+	}
+}
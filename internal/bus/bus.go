@@ -0,0 +1,71 @@
+package bus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type Topic string
+
+const (
+	ClubMemberAdded       Topic = "club.member_added"
+	ClubMemberRemoved     Topic = "club.member_removed"
+	ClubMemberRoleChanged Topic = "club.member_role_changed"
+	ClubTransferRequested Topic = "club.transfer_requested"
+	ClubStatusChanged     Topic = "club.status_changed"
+	EventCreated          Topic = "event.created"
+	EventUpdated          Topic = "event.updated"
+	EventCancelled        Topic = "event.cancelled"
+	EventDeleted          Topic = "event.deleted"
+	FeedPostCreated       Topic = "post.created"
+	NotificationCreated   Topic = "notification.created"
+)
+
+// Message describes a domain change. UserID is the user the change is
+// about (e.g. the member whose role changed), ActorID the user who made it.
+type Message struct {
+	Topic      Topic     `json:"topic"`
+	ClubID     string    `json:"club_id,omitempty"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	UserID     string    `json:"user_id,omitempty"`
+	Data       any       `json:"data,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+type Handler func(ctx context.Context, msg Message)
+
+// Bus is an in-process publish/subscribe hub for domain messages. Handlers
+// run synchronously in the order they subscribed.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+func New() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish delivers msg to every handler. The context is detached from
+// cancellation so handlers can finish after the HTTP response was written.
+func (b *Bus) Publish(ctx context.Context, msg Message) {
+	if msg.OccurredAt.IsZero() {
+		msg.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := make([]Handler, len(b.handlers))
+	copy(handlers, b.handlers)
+	b.mu.RUnlock()
+
+	ctx = context.WithoutCancel(ctx)
+	for _, handler := range handlers {
+		handler(ctx, msg)
+	}
+}
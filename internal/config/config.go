@@ -0,0 +1,489 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+
+	db "api/pkg/database"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Secret is a string that is redacted whenever it is printed or encoded.
+// Use Value to read the actual secret.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+func (s Secret) GoString() string {
+	return fmt.Sprintf("%q", s.String())
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+func (s Secret) MarshalYAML() (any, error) {
+	return s.String(), nil
+}
+
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Log       LogConfig       `yaml:"log"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Database  DatabaseConfig  `yaml:"database"`
+	SMTP      SMTPConfig      `yaml:"smtp"`
+	Reminders ReminderConfig  `yaml:"reminders"`
+	Trash     TrashConfig     `yaml:"trash"`
+	Grants    GrantConfig     `yaml:"role_grants"`
+	Policies  PolicyConfig    `yaml:"policies"`
+}
+
+type ServerConfig struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout"`
+	// Take the client IP from the last X-Forwarded-For entry. Only safe
+	// behind a proxy that sets the header.
+	TrustForwardedFor bool `yaml:"trust_forwarded_for"`
+}
+
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// MetricsConfig controls the Prometheus endpoint. When Token is set it is
+// served on the API address and scrapes must send the token, otherwise it is
+// served on the separate Addr.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`
+	Token   Secret `yaml:"token"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	ServiceName string  `yaml:"service_name"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	AllowedHeaders   []string      `yaml:"allowed_headers"`
+	ExposedHeaders   []string      `yaml:"exposed_headers"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Store is memory for a single instance or postgres to share the limits
+	// between instances.
+	Store string `yaml:"store"`
+	Read  Limit  `yaml:"read"`
+	Write Limit  `yaml:"write"`
+}
+
+type Limit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"`
+}
+
+type DatabaseConfig struct {
+	Host            string        `yaml:"host"`
+	Port            int           `yaml:"port"`
+	User            string        `yaml:"user"`
+	Password        Secret        `yaml:"password"`
+	Name            string        `yaml:"name"`
+	SSLMode         string        `yaml:"ssl_mode"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+	AutoMigrate     bool          `yaml:"auto_migrate"`
+}
+
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	Password Secret `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+type ReminderConfig struct {
+	Offsets  []time.Duration `yaml:"offsets"`
+	Interval time.Duration   `yaml:"interval"`
+}
+
+// TrashConfig controls how long deleted clubs, events and posts can be
+// restored before the purge job removes them for good.
+type TrashConfig struct {
+	RetentionDays int           `yaml:"retention_days"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+func (t TrashConfig) Retention() time.Duration {
+	return time.Duration(t.RetentionDays) * 24 * time.Hour
+}
+
+// GrantConfig controls the job that warns members before their time-limited
+// role expires and removes it once it has.
+type GrantConfig struct {
+	ExpiryNotice time.Duration `yaml:"expiry_notice"`
+	Interval     time.Duration `yaml:"interval"`
+}
+
+// PolicyConfig points at the authorization policy file. The policies built
+// into the server are used when File is empty.
+type PolicyConfig struct {
+	File string `yaml:"file"`
+}
+
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Addr:    ":9090",
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "community-portal-api",
+			SampleRatio: 1,
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   []string{"http://localhost:3000"},
+			AllowCredentials: true,
+			AllowedHeaders: []string{
+				"Content-Type", "Authorization", "X-Requested-With", "X-Request-ID", "If-None-Match",
+				"club-id", "event-id", "notification-id", "webhook-id", "delivery-id",
+				"social-account-id", "social-post-id", "transfer-id",
+				"post-id",
+			},
+			ExposedHeaders: []string{
+				"X-Request-ID", "RateLimit-Policy", "RateLimit-Limit", "RateLimit-Remaining",
+				"RateLimit-Reset", "Retry-After", "ETag", "X-Export-Truncated",
+			},
+			MaxAge: 10 * time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Store:   "memory",
+			Read:    Limit{Requests: 300, Per: time.Minute, Burst: 100},
+			Write:   Limit{Requests: 60, Per: time.Minute, Burst: 20},
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnectTimeout:  time.Minute,
+			AutoMigrate:     true,
+		},
+		SMTP: SMTPConfig{
+			Port: 587,
+		},
+		Reminders: ReminderConfig{
+			Offsets:  []time.Duration{24 * time.Hour, time.Hour},
+			Interval: time.Minute,
+		},
+		Trash: TrashConfig{
+			RetentionDays: 30,
+			PurgeInterval: time.Hour,
+		},
+		Grants: GrantConfig{
+			ExpiryNotice: 24 * time.Hour,
+			Interval:     5 * time.Minute,
+		},
+	}
+}
+
+// DB returns the settings in the form pkg/database expects.
+func (d DatabaseConfig) DB() db.Config {
+	return db.Config{
+		Host:            d.Host,
+		Port:            d.Port,
+		User:            d.User,
+		Password:        d.Password.Value(),
+		DBName:          d.Name,
+		SSLMode:         d.SSLMode,
+		MaxOpenConns:    d.MaxOpenConns,
+		MaxIdleConns:    d.MaxIdleConns,
+		ConnMaxLifetime: d.ConnMaxLifetime,
+		ConnectTimeout:  d.ConnectTimeout,
+	}
+}
+
+// ValidationError lists every problem found in the configuration.
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(v.Problems, "\n  - ")
+}
+
+// Load builds the configuration from the defaults, an optional YAML file,
+// the environment and the command line flags in args, each source
+// overriding the previous one. It returns the arguments left after the
+// flags.
+func Load(args []string) (*Config, []string, error) {
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file")
+	addr := fs.String("addr", "", "address the HTTP server listens on")
+	logLevel := fs.String("log-level", "", "log level: debug, info, warn or error")
+	dbHost := fs.String("db-host", "", "database host")
+	dbPort := fs.Int("db-port", 0, "database port")
+	dbName := fs.String("db-name", "", "database name")
+	dbUser := fs.String("db-user", "", "database user")
+	dbSSLMode := fs.String("db-ssl-mode", "", "database SSL mode")
+	autoMigrate := fs.Bool("auto-migrate", true, "apply pending migrations on startup")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	config := Default()
+
+	if *configFile != "" {
+		if err := config.loadFile(*configFile); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	env := envLoader{lookup: os.LookupEnv}
+	env.apply(&config)
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "addr":
+			config.Server.Addr = *addr
+		case "log-level":
+			config.Log.Level = *logLevel
+		case "db-host":
+			config.Database.Host = *dbHost
+		case "db-port":
+			config.Database.Port = *dbPort
+		case "db-name":
+			config.Database.Name = *dbName
+		case "db-user":
+			config.Database.User = *dbUser
+		case "db-ssl-mode":
+			config.Database.SSLMode = *dbSSLMode
+		case "auto-migrate":
+			config.Database.AutoMigrate = *autoMigrate
+		}
+	})
+
+	problems := append(env.problems, config.validate()...)
+	if len(problems) > 0 {
+		return nil, nil, &ValidationError{Problems: problems}
+	}
+
+	return &config, fs.Args(), nil
+}
+
+func (c *Config) loadFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	// An empty file decodes to io.EOF and keeps the defaults
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+var sslModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+func (c *Config) validate() []string {
+	var problems []string
+
+	if c.Server.Addr == "" {
+		problems = append(problems, "server.addr is required")
+	}
+	for name, timeout := range map[string]time.Duration{
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.shutdown_timeout":    c.Server.ShutdownTimeout,
+	} {
+		if timeout <= 0 {
+			problems = append(problems, name+" must be positive")
+		}
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		problems = append(problems, fmt.Sprintf("log.level %q must be one of debug, info, warn or error", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		problems = append(problems, fmt.Sprintf("log.format %q must be json or text", c.Log.Format))
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Token == "" {
+		if c.Metrics.Addr == "" {
+			problems = append(problems, "metrics.addr or metrics.token is required when metrics are enabled")
+		} else if c.Metrics.Addr == c.Server.Addr {
+			problems = append(problems, "metrics.addr must differ from server.addr, set metrics.token to serve metrics on the API address")
+		}
+	}
+
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter %q must be none, stdout or otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.ServiceName == "" {
+		problems = append(problems, "tracing.service_name is required")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		problems = append(problems, "tracing.sample_ratio must be between 0 and 1")
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				problems = append(problems, `cors.allowed_origins must not contain "*" when cors.allow_credentials is set`)
+			}
+			continue
+		}
+		scheme, host, ok := strings.Cut(origin, "://")
+		if !ok || (scheme != "http" && scheme != "https") || host == "" || strings.ContainsAny(host, "/?#") || strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+			problems = append(problems, fmt.Sprintf("cors.allowed_origins entry %q must look like https://example.com or https://*.example.com", origin))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		problems = append(problems, "cors.max_age must not be negative")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.Store != "memory" && c.RateLimit.Store != "postgres" {
+			problems = append(problems, fmt.Sprintf("rate_limit.store %q must be memory or postgres", c.RateLimit.Store))
+		}
+		for name, limit := range map[string]Limit{
+			"rate_limit.read":  c.RateLimit.Read,
+			"rate_limit.write": c.RateLimit.Write,
+		} {
+			if limit.Requests < 1 || limit.Per <= 0 || limit.Burst < 0 {
+				problems = append(problems, name+" needs at least 1 request per positive duration and a burst that is not negative")
+			}
+		}
+	}
+
+	if c.Database.Host == "" {
+		problems = append(problems, "database.host is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		problems = append(problems, "database.port must be between 1 and 65535")
+	}
+	if c.Database.User == "" {
+		problems = append(problems, "database.user is required")
+	}
+	if c.Database.Name == "" {
+		problems = append(problems, "database.name is required")
+	}
+	if !sslModes[c.Database.SSLMode] {
+		problems = append(problems, fmt.Sprintf("database.ssl_mode %q is not a valid sslmode", c.Database.SSLMode))
+	}
+	if c.Database.MaxOpenConns < 1 {
+		problems = append(problems, "database.max_open_conns must be at least 1")
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "database.max_idle_conns must be between 0 and database.max_open_conns")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		problems = append(problems, "database.conn_max_lifetime must not be negative")
+	}
+	if c.Database.ConnectTimeout <= 0 {
+		problems = append(problems, "database.connect_timeout must be positive")
+	}
+
+	if c.SMTP.Host != "" {
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			problems = append(problems, "smtp.port must be between 1 and 65535")
+		}
+		if c.SMTP.From == "" {
+			problems = append(problems, "smtp.from is required when smtp.host is set")
+		} else if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+			problems = append(problems, fmt.Sprintf("smtp.from %q is not a valid address", c.SMTP.From))
+		}
+	}
+
+	for _, offset := range c.Reminders.Offsets {
+		if offset < time.Minute {
+			problems = append(problems, fmt.Sprintf("reminders.offsets entry %s must be at least 1m", offset))
+		}
+	}
+	if c.Reminders.Interval <= 0 {
+		problems = append(problems, "reminders.interval must be positive")
+	}
+
+	if c.Trash.RetentionDays < 1 {
+		problems = append(problems, "trash.retention_days must be at least 1")
+	}
+	if c.Trash.PurgeInterval <= 0 {
+		problems = append(problems, "trash.purge_interval must be positive")
+	}
+
+	if c.Grants.ExpiryNotice < 0 {
+		problems = append(problems, "role_grants.expiry_notice must not be negative")
+	}
+	if c.Grants.Interval <= 0 {
+		problems = append(problems, "role_grants.interval must be positive")
+	}
+
+	return problems
+}
@@ -0,0 +1,217 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envLoader overrides configuration fields from environment variables and
+// collects the values it could not parse.
+type envLoader struct {
+	lookup   func(key string) (string, bool)
+	problems []string
+}
+
+func (e *envLoader) apply(c *Config) {
+	e.string("SERVER_ADDR", &c.Server.Addr)
+	e.duration("HTTP_READ_HEADER_TIMEOUT", &c.Server.ReadHeaderTimeout)
+	e.duration("HTTP_READ_TIMEOUT", &c.Server.ReadTimeout)
+	e.duration("HTTP_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	e.duration("HTTP_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	e.duration("SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	e.bool("TRUST_FORWARDED_FOR", &c.Server.TrustForwardedFor)
+
+	e.string("LOG_LEVEL", &c.Log.Level)
+	e.string("LOG_FORMAT", &c.Log.Format)
+
+	e.bool("METRICS_ENABLED", &c.Metrics.Enabled)
+	e.string("METRICS_ADDR", &c.Metrics.Addr)
+	e.secret("METRICS_TOKEN", &c.Metrics.Token)
+
+	e.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	e.string("OTEL_SERVICE_NAME", &c.Tracing.ServiceName)
+	e.float("TRACING_SAMPLE_RATIO", &c.Tracing.SampleRatio)
+
+	e.strings("CORS_ALLOWED_ORIGINS", &c.CORS.AllowedOrigins)
+	e.bool("CORS_ALLOW_CREDENTIALS", &c.CORS.AllowCredentials)
+	e.strings("CORS_ALLOWED_HEADERS", &c.CORS.AllowedHeaders)
+	e.strings("CORS_EXPOSED_HEADERS", &c.CORS.ExposedHeaders)
+	e.duration("CORS_MAX_AGE", &c.CORS.MaxAge)
+
+	e.bool("RATE_LIMIT_ENABLED", &c.RateLimit.Enabled)
+	e.string("RATE_LIMIT_STORE", &c.RateLimit.Store)
+	e.limit("RATE_LIMIT_READ", &c.RateLimit.Read)
+	e.limit("RATE_LIMIT_WRITE", &c.RateLimit.Write)
+
+	e.string("DB_HOST", &c.Database.Host)
+	e.int("DB_PORT", &c.Database.Port)
+	e.string("DB_USER", &c.Database.User)
+	e.secret("DB_PASSWORD", &c.Database.Password)
+	e.string("DB_NAME", &c.Database.Name)
+	// DB_SLL_MODE is the misspelled name older deployments still use
+	if value, ok := e.lookup("DB_SLL_MODE"); ok && value != "" {
+		slog.Warn("DB_SLL_MODE is deprecated, use DB_SSL_MODE")
+		c.Database.SSLMode = value
+	}
+	e.string("DB_SSL_MODE", &c.Database.SSLMode)
+	e.int("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.int("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.duration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.duration("DB_CONNECT_TIMEOUT", &c.Database.ConnectTimeout)
+	e.bool("DB_AUTO_MIGRATE", &c.Database.AutoMigrate)
+
+	e.string("SMTP_HOST", &c.SMTP.Host)
+	e.int("SMTP_PORT", &c.SMTP.Port)
+	e.string("SMTP_USER", &c.SMTP.Username)
+	e.secret("SMTP_PASSWORD", &c.SMTP.Password)
+	e.string("SMTP_FROM", &c.SMTP.From)
+
+	e.durations("EVENT_REMINDER_OFFSETS", &c.Reminders.Offsets)
+	e.duration("EVENT_REMINDER_INTERVAL", &c.Reminders.Interval)
+
+	e.int("TRASH_RETENTION_DAYS", &c.Trash.RetentionDays)
+	e.duration("TRASH_PURGE_INTERVAL", &c.Trash.PurgeInterval)
+
+	e.duration("ROLE_GRANT_EXPIRY_NOTICE", &c.Grants.ExpiryNotice)
+	e.duration("ROLE_GRANT_INTERVAL", &c.Grants.Interval)
+
+	e.string("POLICY_FILE", &c.Policies.File)
+}
+
+// Empty variables are treated as unset so .env templates with blank
+// entries keep the defaults.
+func (e *envLoader) get(key string) (string, bool) {
+	value, ok := e.lookup(key)
+	if !ok || value == "" {
+		return "", false
+	}
+	return value, true
+}
+
+func (e *envLoader) invalid(key, value string) {
+	e.problems = append(e.problems, fmt.Sprintf("invalid %s value '%s'", key, value))
+}
+
+func (e *envLoader) string(key string, dst *string) {
+	if value, ok := e.get(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envLoader) secret(key string, dst *Secret) {
+	if value, ok := e.get(key); ok {
+		*dst = Secret(value)
+	}
+}
+
+func (e *envLoader) int(key string, dst *int) {
+	if value, ok := e.get(key); ok {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			e.invalid(key, value)
+			return
+		}
+		*dst = i
+	}
+}
+
+func (e *envLoader) float(key string, dst *float64) {
+	if value, ok := e.get(key); ok {
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			e.invalid(key, value)
+			return
+		}
+		*dst = f
+	}
+}
+
+func (e *envLoader) bool(key string, dst *bool) {
+	if value, ok := e.get(key); ok {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			e.invalid(key, value)
+			return
+		}
+		*dst = b
+	}
+}
+
+func (e *envLoader) duration(key string, dst *time.Duration) {
+	if value, ok := e.get(key); ok {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			e.invalid(key, value)
+			return
+		}
+		*dst = d
+	}
+}
+
+// Reads a comma separated list such as "https://a.example.com,https://b.example.com".
+func (e *envLoader) strings(key string, dst *[]string) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	*dst = values
+}
+
+// Reads a limit such as "60/1m", the burst is left unchanged.
+func (e *envLoader) limit(key string, dst *Limit) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+
+	requests, per, ok := strings.Cut(value, "/")
+	if !ok {
+		e.invalid(key, value)
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil {
+		e.invalid(key, value)
+		return
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(per))
+	if err != nil {
+		e.invalid(key, value)
+		return
+	}
+	dst.Requests = n
+	dst.Per = d
+}
+
+// Reads a comma separated list of durations such as "24h,1h".
+func (e *envLoader) durations(key string, dst *[]time.Duration) {
+	value, ok := e.get(key)
+	if !ok {
+		return
+	}
+
+	var durations []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			e.invalid(key, value)
+			return
+		}
+		durations = append(durations, d)
+	}
+	*dst = durations
+}
@@ -3,72 +3,251 @@ package middleware
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"net/http"
 
+	"api/internal/models"
 	"api/internal/permissions"
+	"api/internal/policy"
 	"api/internal/repository"
+	db "api/pkg/database"
 	"api/pkg/utils"
+
+	"github.com/gorilla/mux"
 )
 
+// ErrInvalidResourceID is returned by GetResource for resource ids that are
+// not UUIDs.
+var ErrInvalidResourceID = errors.New("invalid resource id")
+
 type AuthorizationService struct {
-	db *sql.DB
+	db       *sql.DB
+	policies *policy.Engine
+}
+
+func NewAuthorizationService(db *sql.DB, policies *policy.Engine) *AuthorizationService {
+	return &AuthorizationService{db: db, policies: policies}
+}
+
+// GetUserRoles returns the names of the roles the user holds in the club
+// right now, none when they are not a member. Roles granted for a period
+// that has not started yet or is over are ignored.
+func (a *AuthorizationService) GetUserRoles(ctx context.Context, clubID, userID string) ([]string, error) {
+	clubRolesRepository := repository.NewClubUserRepository(db.WithContext(ctx, a.db))
+	roleNames, err := clubRolesRepository.GetActiveUserRoles(clubID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return roleNames, err
+}
+
+// GetTrashedClubRoles is GetUserRoles for a club in the trash.
+func (a *AuthorizationService) GetTrashedClubRoles(ctx context.Context, clubID, userID string) ([]string, error) {
+	clubRolesRepository := repository.NewClubUserRepository(db.WithContext(ctx, a.db))
+	roleNames, err := clubRolesRepository.GetTrashedClubRoles(clubID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return roleNames, err
+}
+
+// GetClubStatus returns the status of the club, deleted when it is in the
+// trash and empty when it does not exist.
+func (a *AuthorizationService) GetClubStatus(ctx context.Context, clubID string) (string, error) {
+	clubRepository := repository.NewClubRepository(db.WithContext(ctx, a.db))
+	return clubRepository.GetClubStatus(clubID)
+}
+
+// GetResource returns the resource a request acts on, nil when it acts on
+// the club itself or the resource does not exist, in which case the handler
+// answers with a 404.
+func (a *AuthorizationService) GetResource(ctx context.Context, r *http.Request) (*policy.Resource, error) {
+	if eventID := r.Header.Get("event-id"); eventID != "" {
+		if !utils.IsUUID(eventID) {
+			return nil, ErrInvalidResourceID
+		}
+		event, err := repository.NewEventRepository(db.WithContext(ctx, a.db)).GetEventByID(eventID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &policy.Resource{
+			Type:   "event",
+			ID:     event.ID,
+			Club:   event.ClubID,
+			Owner:  event.CreatedBy,
+			Status: event.Status,
+		}, nil
+	}
+
+	return nil, nil
+}
+
+// ClubRole returns the union of the roles named roleNames and of the club
+// role of platformRole, see permissions.Combine. It returns nil when there
+// is none.
+func ClubRole(roleNames []string, platformRole *permissions.PlatformRole) *permissions.Role {
+	roles := make([]*permissions.Role, 0, len(roleNames)+1)
+	for _, roleName := range roleNames {
+		roles = append(roles, permissions.GetRoleWithRoleName(roleName))
+	}
+	if platformRole != nil {
+		roles = append(roles, platformRole.Club)
+	}
+	return permissions.Combine(roles...)
+}
+
+// Actor returns the policy attributes of a user holding roleNames in a
+// club and platformRole across clubs.
+func Actor(userID string, roleNames []string, platformRole *permissions.PlatformRole) policy.Actor {
+	actor := policy.Actor{ID: userID, Roles: roleNames}
+	if platformRole != nil {
+		actor.PlatformRole = platformRole.Name
+	}
+	return actor
+}
+
+// Evaluate decides whether the caller may use permission, see
+// policy.Engine.Evaluate.
+func (a *AuthorizationService) Evaluate(request policy.Request) policy.Decision {
+	return a.policies.Evaluate(request)
+}
+
+// routeOf returns the path template of the route matched by r, empty when
+// there is none.
+func routeOf(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
 }
 
-func NewAuthorizationService(db *sql.DB) *AuthorizationService {
-	return &AuthorizationService{db: db}
+// PolicyRequestFrom returns the policy request CheckPermission or
+// CheckMembership built for the caller, for handlers that evaluate more
+// permissions once they know what the request acts on.
+func PolicyRequestFrom(ctx context.Context) (policy.Request, bool) {
+	request, ok := ctx.Value("policyRequest").(policy.Request)
+	return request, ok
 }
 
-func (a *AuthorizationService) GetUserRole(clubID, userID string) (*permissions.Role, error) {
-	clubRolesRepository := repository.NewClubUserRepository(a.db)
-	roleName, err := clubRolesRepository.GetUserRole(clubID, userID)
+// loadCaller builds the policy request of the caller in the club named by
+// the club-id header, without a permission. It answers the request itself
+// and returns false when the caller cannot go further.
+func loadCaller(authService *AuthorizationService, w http.ResponseWriter, r *http.Request) (context.Context, policy.Request, bool) {
+	claims, ok := utils.GetTokenClaims(r)
+	if !ok {
+		utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, policy.Request{}, false
+	}
+
+	userID, ok := utils.GetUserIDFromClaims(claims)
+	if !ok {
+		utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, policy.Request{}, false
+	}
+
+	clubID := r.Header.Get("club-id")
+	if clubID == "" {
+		utils.JSONError(w, http.StatusBadRequest, "Club ID is required")
+		return nil, policy.Request{}, false
+	}
+
+	ctx := withRequestClub(r.Context(), clubID)
+	status, err := authService.GetClubStatus(ctx, clubID)
+	if err != nil {
+		utils.JSONError(w, http.StatusInternalServerError, "Unable to get club")
+		return nil, policy.Request{}, false
+	}
+
+	// Members keep their roles in a club in the trash, the deleted-club
+	// policy decides what they may still do there
+	var roleNames []string
+	if status == models.ClubStatusDeleted {
+		roleNames, err = authService.GetTrashedClubRoles(ctx, clubID, userID)
+	} else {
+		roleNames, err = authService.GetUserRoles(ctx, clubID, userID)
+	}
 	if err != nil {
-		return nil, err
+		utils.JSONError(w, http.StatusInternalServerError, "Unable to get user role")
+		return nil, policy.Request{}, false
+	}
+
+	// Platform staff hold their platform role in every club, on top of the
+	// roles they hold there
+	platformRole := PlatformRoleFrom(ctx)
+	role := ClubRole(roleNames, platformRole)
+	if role == nil {
+		utils.JSONError(w, http.StatusForbidden, "Forbidden: you are not a member of this club")
+		return nil, policy.Request{}, false
 	}
 
-	return permissions.GetRoleWithRoleName(roleName), nil
+	return ctx, policy.Request{
+		Actor: Actor(userID, roleNames, platformRole),
+		Club:  policy.Club{ID: clubID, Status: status},
+		Role:  role,
+		Route: routeOf(r),
+	}, true
 }
 
-func (a *AuthorizationService) HasPermission(role *permissions.Role, permission permissions.Permission) bool {
-	return role.HasPermission(permission)
+// withCaller stores the caller of request in ctx for the handlers.
+func withCaller(ctx context.Context, request policy.Request) context.Context {
+	ctx = context.WithValue(ctx, "userRole", request.Role)
+	ctx = context.WithValue(ctx, "userRoles", request.Actor.Roles)
+	ctx = context.WithValue(ctx, "userId", request.Actor.ID)
+	ctx = context.WithValue(ctx, "clubId", request.Club.ID)
+	return context.WithValue(ctx, "policyRequest", request)
 }
 
 func CheckPermission(authService *AuthorizationService, permission permissions.Permission) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			claims, ok := utils.GetTokenClaims(r)
+			ctx, request, ok := loadCaller(authService, w, r)
 			if !ok {
-				utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
 				return
 			}
 
-			userID, ok := utils.GetUserIDFromClaims(claims)
-			if !ok {
-				utils.JSONError(w, http.StatusUnauthorized, "Unauthorized")
+			resource, err := authService.GetResource(ctx, r)
+			if errors.Is(err, ErrInvalidResourceID) {
+				utils.JSONError(w, http.StatusBadRequest, "Invalid resource id")
 				return
 			}
-
-			clubID := r.Header.Get("club-id")
-			if clubID == "" {
-				utils.JSONError(w, http.StatusBadRequest, "Club ID is required")
+			if err != nil {
+				utils.JSONError(w, http.StatusInternalServerError, "Unable to get resource")
 				return
 			}
 
-			role, err := authService.GetUserRole(clubID, userID)
-			if err != nil || role == nil {
-				utils.JSONError(w, http.StatusInternalServerError, "Unable to get user role")
+			request.Resource = resource
+			request.Permission = permission
+			decision := authService.Evaluate(request)
+			if !decision.Allowed {
+				utils.JSONError(w, http.StatusForbidden, "Forbidden: "+decision.Reason)
 				return
 			}
 
-			if !authService.HasPermission(role, permission) {
-				utils.JSONError(w, http.StatusForbidden, "Forbidden")
+			next.ServeHTTP(w, r.WithContext(withCaller(ctx, request)))
+		})
+	}
+}
+
+// CheckMembership only lets members of the club through, for routes whose
+// permission depends on the body of the request, such as restoring an item
+// from the trash. The handler evaluates the permission with the policy
+// request from PolicyRequestFrom.
+func CheckMembership(authService *AuthorizationService) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, request, ok := loadCaller(authService, w, r)
+			if !ok {
 				return
 			}
-
-			ctx := r.Context()
-			ctx = context.WithValue(ctx, "userRole", role)
-			ctx = context.WithValue(ctx, "userId", userID)
-			ctx = context.WithValue(ctx, "clubId", clubID)
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withCaller(ctx, request)))
 		})
 	}
 }
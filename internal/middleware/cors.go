@@ -1,18 +1,162 @@
 package middleware
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
-func CorsMiddleware(handler http.Handler) http.Handler {
+	"github.com/gorilla/mux"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins are matched against the Origin header. An entry like
+	// https://*.example.com allows every subdomain of example.com and "*"
+	// allows any origin, which is refused together with AllowCredentials.
+	AllowedOrigins   []string
+	AllowCredentials bool
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	MaxAge           time.Duration
+}
+
+// preflightMethods are the methods a preflight request is answered with when
+// the router has a route for them on the requested path.
+var preflightMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+type cors struct {
+	config  CORSConfig
+	router  *mux.Router
+	origins []originPattern
+}
+
+type originPattern struct {
+	any    bool
+	scheme string
+	host   string
+	// Set for *.example.com patterns, host is then ".example.com"
+	subdomains bool
+}
+
+// CORS answers preflight requests and adds the CORS headers for allowed
+// origins. The methods allowed for a path are taken from the routes of
+// router, so every route needs to accept OPTIONS for its preflight to reach
+// this middleware.
+func CORS(config CORSConfig, router *mux.Router) mux.MiddlewareFunc {
+	c := &cors{
+		config: config,
+		router: router,
+	}
+	for _, origin := range config.AllowedOrigins {
+		c.origins = append(c.origins, parseOriginPattern(origin))
+	}
+
+	return c.handler
+}
+
+func parseOriginPattern(origin string) originPattern {
+	if origin == "*" {
+		return originPattern{any: true}
+	}
+
+	scheme, host, _ := strings.Cut(strings.ToLower(origin), "://")
+	if rest, ok := strings.CutPrefix(host, "*."); ok {
+		return originPattern{scheme: scheme, host: "." + rest, subdomains: true}
+	}
+	return originPattern{scheme: scheme, host: host}
+}
+
+func (c *cors) allowed(origin string) bool {
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+
+	for _, pattern := range c.origins {
+		switch {
+		case pattern.any:
+			return true
+		case pattern.scheme != u.Scheme:
+			continue
+		case pattern.subdomains && strings.HasSuffix(u.Host, pattern.host):
+			return true
+		case !pattern.subdomains && pattern.host == u.Host:
+			return true
+		}
+	}
+	return false
+}
+
+func (c *cors) handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Requested-With, *")
+		origin := r.Header.Get("Origin")
+		w.Header().Add("Vary", "Origin")
+
+		if origin != "" && c.allowed(origin) {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if c.config.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
 
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				c.preflight(w, r)
+				return
+			}
+
+			if len(c.config.ExposedHeaders) > 0 {
+				w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.config.ExposedHeaders, ", "))
+			}
+		}
+
+		// Routes accept OPTIONS only for preflight requests, handlers never
+		// see them
 		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
 
-		handler.ServeHTTP(w, r)
+		next.ServeHTTP(w, r)
 	})
 }
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Access-Control-Request-Method")
+	w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+	methods := c.routeMethods(r)
+	if len(methods) > 0 {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	}
+	if len(c.config.AllowedHeaders) > 0 {
+		w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.config.AllowedHeaders, ", "))
+	}
+	if c.config.MaxAge > 0 {
+		w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.config.MaxAge/time.Second)))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// routeMethods returns the methods the router has a route for on the path of
+// r.
+func (c *cors) routeMethods(r *http.Request) []string {
+	var methods []string
+	for _, method := range preflightMethods {
+		probe := r.WithContext(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if c.router.Match(probe, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
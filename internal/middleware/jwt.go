@@ -31,6 +31,9 @@ func EnsureValidToken(next http.Handler) http.Handler {
 		}
 
 		ctx := context.WithValue(r.Context(), "tokenClaims", tokenClaims)
+		if sub, ok := tokenClaims["sub"].(string); ok {
+			ctx = withRequestUser(ctx, sub)
+		}
 		r = r.WithContext(ctx)
 
 		next.ServeHTTP(w, r)
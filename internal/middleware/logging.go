@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"api/internal/metrics"
+	"api/pkg/logging"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+type requestInfoKey struct{}
+
+// requestInfo is filled in while the request travels through the router and
+// the handlers, and written to the access log once it is done.
+type requestInfo struct {
+	route  string
+	userID string
+	clubID string
+}
+
+// RequestLogger assigns every request an ID, taken from the X-Request-ID
+// header when the client sent one, stores a logger carrying it in the request
+// context, writes one access log line per request and records the request
+// in the HTTP metrics.
+//
+// It wraps the router rather than being registered with Use so requests that
+// do not match any route are logged as well.
+func RequestLogger(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		requestLogger := logger.With("request_id", requestID)
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.IsValid() {
+			requestLogger = requestLogger.With("trace_id", spanContext.TraceID().String())
+		}
+		info := &requestInfo{}
+
+		ctx := r.Context()
+		ctx = logging.WithRequestID(ctx, requestID)
+		ctx = logging.WithLogger(ctx, requestLogger)
+		ctx = context.WithValue(ctx, requestInfoKey{}, info)
+
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		latency := time.Since(start)
+		metrics.ObserveRequest(r.Method, info.route, status, latency)
+
+		attrs := []any{
+			"method", r.Method,
+			"route", info.route,
+			"path", r.URL.Path,
+			"status", status,
+			"latency_ms", latency.Milliseconds(),
+			"bytes", recorder.bytes,
+		}
+		if info.userID != "" {
+			attrs = append(attrs, "sub", info.userID)
+		}
+		if info.clubID != "" {
+			attrs = append(attrs, "club_id", info.clubID)
+		}
+		if recorder.err != "" {
+			attrs = append(attrs, "error", recorder.err)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		requestLogger.Log(ctx, level, "request", attrs...)
+	})
+}
+
+// RecordRoute stores the path template of the matched route, e.g. /api/event,
+// for the access log and the metrics, and names the request span after it.
+func RecordRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if route := mux.CurrentRoute(r); route != nil {
+			if template, err := route.GetPathTemplate(); err == nil {
+				setRequestInfo(r.Context(), func(info *requestInfo) { info.route = template })
+
+				span := trace.SpanFromContext(r.Context())
+				span.SetName(r.Method + " " + template)
+				span.SetAttributes(attribute.String("http.route", template))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func setRequestInfo(ctx context.Context, set func(info *requestInfo)) {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		set(info)
+	}
+}
+
+// withRequestUser records the authenticated user for the access log and adds
+// it to the request logger.
+func withRequestUser(ctx context.Context, userID string) context.Context {
+	setRequestInfo(ctx, func(info *requestInfo) { info.userID = userID })
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("enduser.id", userID))
+	return logging.WithLogger(ctx, logging.FromContext(ctx).With("sub", userID))
+}
+
+// withRequestClub records the club a request acts on for the access log and
+// adds it to the request logger.
+func withRequestClub(ctx context.Context, clubID string) context.Context {
+	setRequestInfo(ctx, func(info *requestInfo) { info.clubID = clubID })
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("club.id", clubID))
+	return logging.WithLogger(ctx, logging.FromContext(ctx).With("club_id", clubID))
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+	err    string
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(b)
+	s.bytes += n
+	return n, err
+}
+
+// SetError is called by utils.JSONError so the error message ends up in the
+// access log.
+func (s *statusRecorder) SetError(message string) {
+	s.err = message
+}
+
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"api/internal/permissions"
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/utils"
+)
+
+// GetAccount returns the platform role of the user, nil for most users, and
+// whether they are suspended.
+func (a *AuthorizationService) GetAccount(ctx context.Context, userID string) (*permissions.PlatformRole, bool, error) {
+	platformRepository := repository.NewPlatformRepository(db.WithContext(ctx, a.db))
+	roleName, suspended, err := platformRepository.GetUserAccount(userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return permissions.GetPlatformRoleWithName(roleName), suspended, nil
+}
+
+// PlatformRoleFrom returns the platform role CheckAccount stored in ctx, nil
+// when the caller has none.
+func PlatformRoleFrom(ctx context.Context) *permissions.PlatformRole {
+	role, _ := ctx.Value("platformRole").(*permissions.PlatformRole)
+	return role
+}
+
+// CheckAccount rejects suspended users and stores the platform role of the
+// caller in the request context, where CheckPermission and
+// RequirePlatformPermission pick it up. It runs after EnsureValidToken.
+func CheckAccount(authService *AuthorizationService) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := utils.GetTokenClaims(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			userID, ok := utils.GetUserIDFromClaims(claims)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			role, suspended, err := authService.GetAccount(r.Context(), userID)
+			if err != nil {
+				utils.JSONError(w, http.StatusInternalServerError, "Unable to get user account")
+				return
+			}
+			if suspended {
+				utils.JSONError(w, http.StatusForbidden, "Forbidden: your account is suspended")
+				return
+			}
+
+			if role != nil {
+				r = r.WithContext(context.WithValue(r.Context(), "platformRole", role))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequirePlatformPermission only lets platform staff whose role grants
+// permission through.
+func RequirePlatformPermission(permission permissions.PlatformPermission) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role := PlatformRoleFrom(r.Context())
+			if role == nil {
+				utils.JSONError(w, http.StatusForbidden, "Forbidden: platform role required")
+				return
+			}
+			if !role.HasPermission(permission) {
+				utils.JSONError(w, http.StatusForbidden, fmt.Sprintf("Forbidden: the %s role does not grant %s", role.Name, permission))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"api/internal/metrics"
+	"api/internal/ratelimit"
+	"api/pkg/logging"
+	"api/pkg/utils"
+)
+
+// Rate limit classes. Requests are limited as read or write by their
+// method.
+const (
+	RateLimitRead  = "read"
+	RateLimitWrite = "write"
+)
+
+type RateLimiter struct {
+	store  ratelimit.Store
+	limits map[string]ratelimit.Limit
+}
+
+// NewRateLimiter limits requests per class with limits. A nil store
+// disables rate limiting.
+func NewRateLimiter(store ratelimit.Store, limits map[string]ratelimit.Limit) *RateLimiter {
+	return &RateLimiter{
+		store:  store,
+		limits: limits,
+	}
+}
+
+// Limit limits requests as read or write depending on their method.
+func (l *RateLimiter) Limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := RateLimitWrite
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			class = RateLimitRead
+		}
+		l.serve(class, next, w, r)
+	})
+}
+
+// LimitClass limits requests with the limit of class.
+func (l *RateLimiter) LimitClass(class string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			l.serve(class, next, w, r)
+		}
+	}
+}
+
+func (l *RateLimiter) serve(class string, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	limit, ok := l.limits[class]
+	if l.store == nil || !ok || r.Method == http.MethodOptions {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	key := class + ":" + l.clientKey(r)
+	result, err := l.store.Take(r.Context(), key, limit)
+	if err != nil {
+		// A broken store must not take the API down with it
+		logging.FromContext(r.Context()).Error("failed to check rate limit", "class", class, "error", err)
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+strconv.Itoa(int(limit.Per.Seconds())))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(int(result.Reset.Seconds())))
+
+	if !result.Allowed {
+		metrics.RateLimited.WithLabelValues(class).Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+		utils.JSONError(w, http.StatusTooManyRequests, "Too many requests")
+		return
+	}
+
+	next.ServeHTTP(w, r)
+}
+
+// clientKey identifies the client by the JWT sub of authenticated requests
+// and by IP otherwise.
+func (l *RateLimiter) clientKey(r *http.Request) string {
+	if claims, ok := utils.GetTokenClaims(r); ok {
+		if userID, ok := utils.GetUserIDFromClaims(claims); ok {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + utils.ClientIP(r)
+}
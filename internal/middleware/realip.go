@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// RealIP replaces the remote address of requests with the last
+// X-Forwarded-For entry when trustForwardedFor is set, so rate limits and
+// the audit log see the client rather than the proxy.
+func RealIP(trustForwardedFor bool, next http.Handler) http.Handler {
+	if !trustForwardedFor {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := net.ParseIP(strings.TrimSpace(parts[len(parts)-1])); ip != nil {
+				r.RemoteAddr = net.JoinHostPort(ip.String(), "0")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
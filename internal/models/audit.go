@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AuditEntry struct {
+	ID          string          `json:"id"`
+	ClubID      string          `json:"club_id"`
+	ActorUserID string          `json:"actor_user_id"`
+	Action      string          `json:"action"`
+	TargetType  string          `json:"target_type"`
+	TargetID    string          `json:"target_id"`
+	Before      json.RawMessage `json:"before,omitempty"`
+	After       json.RawMessage `json:"after,omitempty"`
+	Changes     json.RawMessage `json:"changes,omitempty"`
+	IP          string          `json:"ip"`
+	RequestID   string          `json:"request_id"`
+	CreatedAt   string          `json:"created_at"`
+}
+
+// AuditFilter narrows down the audit entries of a club. Empty fields match
+// every entry.
+type AuditFilter struct {
+	Action      string
+	ActorUserID string
+	TargetType  string
+	TargetID    string
+	Since       *time.Time
+	Until       *time.Time
+	Limit       int
+	Offset      int
+	// AfterCreatedAt and AfterID continue a listing after the entry created
+	// at AfterCreatedAt with id AfterID, the last one of the previous page.
+	// Unlike Offset they neither skip nor repeat entries logged meanwhile.
+	AfterCreatedAt string
+	AfterID        string
+}
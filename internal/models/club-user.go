@@ -1,35 +1,85 @@
 package models
 
+import "time"
+
+// RoleGrant limits a role to a period of time. Nil bounds are open, a grant
+// without bounds never expires.
+type RoleGrant struct {
+	ValidFrom  *time.Time `json:"valid_from,omitempty"`
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	GrantedBy  string     `json:"-"`
+}
+
 type AddClubUserPayload struct {
 	Email string `json:"email"`
 	Role  string `json:"role"`
+	RoleGrant
 }
 
+// DeleteClubUserPayload revokes Role from the member, or removes the member
+// from the club when Role is empty.
 type DeleteClubUserPayload struct {
 	UserID string `json:"user-id"`
+	Role   string `json:"role,omitempty"`
 }
 
+// UpdateClubUserRolePayload replaces all roles of the member with Role.
 type UpdateClubUserRolePayload struct {
 	UserID string `json:"user-id"`
 	Role   string `json:"role"`
+	RoleGrant
 }
 
 type UserClubWithRole struct {
-	ClubID      string `json:"club_id"`
-	ClubName    string `json:"club_name"`
-	Description string `json:"description"`
-	Role        string `json:"role"`
+	ClubID      string   `json:"club_id"`
+	ClubName    string   `json:"club_name"`
+	Description string   `json:"description"`
+	Roles       []string `json:"roles"`
 }
 
 type ClubMember struct {
-	UserID    string `json:"user_id"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Email     string `json:"email"`
-	Role      string `json:"role"`
+	UserID    string           `json:"user_id"`
+	FirstName string           `json:"first_name"`
+	LastName  string           `json:"last_name"`
+	Email     string           `json:"email"`
+	Roles     []ClubMemberRole `json:"roles"`
+}
+
+type ClubMemberRole struct {
+	Role      string     `json:"role"`
+	GrantedBy string     `json:"granted_by,omitempty"`
+	ValidFrom *time.Time `json:"valid_from,omitempty"`
+	// ValidUntil and ExpiresIn are only set for time-limited roles.
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+	ExpiresIn  *int64     `json:"expires_in_seconds,omitempty"`
 }
 
 type ClubDetailsResponse struct {
 	Club    Club         `json:"club"`
 	Members []ClubMember `json:"members"`
 }
+
+// ClubMemberChange describes a change to the roles of a member. Role is the
+// role granted and PreviousRole the one revoked, Roles lists all roles the
+// member holds afterwards.
+type ClubMemberChange struct {
+	Role         string     `json:"role,omitempty"`
+	PreviousRole string     `json:"previous_role,omitempty"`
+	Roles        []string   `json:"roles,omitempty"`
+	ValidFrom    *time.Time `json:"valid_from,omitempty"`
+	ValidUntil   *time.Time `json:"valid_until,omitempty"`
+}
+
+// ExpiringGrant is a time-limited role that is about to expire or has
+// expired.
+type ExpiringGrant struct {
+	UserID           string
+	Email            string
+	EmailPreferences bool
+	ClubID           string
+	ClubName         string
+	Role             string
+	ValidUntil       time.Time
+	// StillMember is set when the member holds other roles in the club.
+	StillMember bool
+}
@@ -1,21 +1,35 @@
 package models
 
+// A new club is pending until a moderator approves or rejects it. Active
+// clubs can be suspended by platform staff and archived by their owners.
+// Clubs in the trash are deleted whatever their stored status.
+const (
+	ClubStatusPending   = "pending"
+	ClubStatusActive    = "active"
+	ClubStatusRejected  = "rejected"
+	ClubStatusSuspended = "suspended"
+	ClubStatusArchived  = "archived"
+	ClubStatusDeleted   = "deleted"
+)
+
 type Club struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Email       string `json:"email"`
 	MemberCount string `json:"member_count"`
+	Status      string `json:"status"`
+	Verified    bool   `json:"verified"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
 
 type ClubWithRole struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Email       string `json:"email"`
-	Role        string `json:"role"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles"`
 }
 
 type CreateClubPayload struct {
@@ -23,3 +37,15 @@ type CreateClubPayload struct {
 	Description string `json:"description"`
 	Email       string `json:"email"`
 }
+
+// ClubStatusPayload gives the reason of a status change, required when a
+// club is rejected.
+type ClubStatusPayload struct {
+	Reason string `json:"reason"`
+}
+
+type ClubStatusChange struct {
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
@@ -1,5 +1,15 @@
 package models
 
+const (
+	EventStatusActive    = "active"
+	EventStatusCancelled = "cancelled"
+)
+
+const (
+	AttendanceGoing    = "going"
+	AttendanceNotGoing = "not_going"
+)
+
 type Event struct {
 	ID          string `json:"id"`
 	ClubID      string `json:"club_id"`
@@ -9,6 +19,8 @@ type Event struct {
 	EndDate     string `json:"end_date"`
 	Tags        string `json:"tags"`
 	Location    string `json:"location"`
+	Status      string `json:"status"`
+	CreatedBy   string `json:"created_by,omitempty"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
 }
@@ -30,3 +42,13 @@ type UpdateEventPayload struct {
 	Tags        string `json:"tags"`
 	Location    string `json:"location"`
 }
+
+type EventAttendee struct {
+	UserID           string `json:"user_id"`
+	Email            string `json:"email"`
+	EmailPreferences bool   `json:"email_preferences"`
+}
+
+type RSVPEventPayload struct {
+	Situation string `json:"situation"`
+}
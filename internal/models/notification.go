@@ -0,0 +1,31 @@
+package models
+
+type Notification struct {
+	ID        string            `json:"id"`
+	UserID    string            `json:"user_id"`
+	ClubID    string            `json:"club_id,omitempty"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	ReadAt    *string           `json:"read_at"`
+	CreatedAt string            `json:"created_at"`
+}
+
+type MarkNotificationsReadPayload struct {
+	IDs []string `json:"ids"`
+}
+
+type UnreadNotificationCount struct {
+	Count int `json:"count"`
+}
+
+type EventReminder struct {
+	ID            string
+	EventID       string
+	ClubID        string
+	Title         string
+	Location      string
+	StartDate     string
+	OffsetMinutes int
+}
@@ -0,0 +1,29 @@
+package models
+
+const (
+	TransferStatusPending   = "pending"
+	TransferStatusAccepted  = "accepted"
+	TransferStatusDeclined  = "declined"
+	TransferStatusCancelled = "cancelled"
+)
+
+// OwnershipTransfer is an owner's offer to hand a club over to another user.
+// It only takes effect once that user accepts it.
+type OwnershipTransfer struct {
+	ID         string `json:"id"`
+	ClubID     string `json:"club_id"`
+	ClubName   string `json:"club_name,omitempty"`
+	FromUserID string `json:"from_user_id"`
+	ToUserID   string `json:"to_user_id"`
+	StayOwner  bool   `json:"stay_owner"`
+	Status     string `json:"status"`
+	ExpiresAt  string `json:"expires_at"`
+	CreatedAt  string `json:"created_at"`
+	UpdatedAt  string `json:"updated_at"`
+}
+
+type CreateOwnershipTransferPayload struct {
+	UserID string `json:"user-id"`
+	// Keep the initiator as a co-owner instead of making them an admin
+	StayOwner bool `json:"stay_owner"`
+}
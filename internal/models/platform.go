@@ -0,0 +1,48 @@
+package models
+
+// AdminClub is a club as platform staff see it, suspended clubs included.
+type AdminClub struct {
+	Club
+	Members         int     `json:"members"`
+	StatusReason    string  `json:"status_reason,omitempty"`
+	StatusChangedBy string  `json:"status_changed_by,omitempty"`
+	StatusChangedAt *string `json:"status_changed_at"`
+}
+
+type PlatformRoleAssignment struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+type AdminClubFilter struct {
+	// Status is one of the club statuses, empty for all clubs
+	Status string
+	Search string
+	Limit  int
+	Offset int
+}
+
+type SuspendUserPayload struct {
+	UserID string `json:"user-id"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Suspension is the audit state of a suspended user.
+type Suspension struct {
+	Suspended bool   `json:"suspended"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+type PlatformStats struct {
+	Users          int64 `json:"users"`
+	SuspendedUsers int64 `json:"suspended_users"`
+	Clubs          int64 `json:"clubs"`
+	PendingClubs   int64 `json:"pending_clubs"`
+	SuspendedClubs int64 `json:"suspended_clubs"`
+	VerifiedClubs  int64 `json:"verified_clubs"`
+	Memberships    int64 `json:"memberships"`
+	Events         int64 `json:"events"`
+	UpcomingEvents int64 `json:"upcoming_events"`
+	Posts          int64 `json:"posts"`
+}
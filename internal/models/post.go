@@ -0,0 +1,17 @@
+package models
+
+type Post struct {
+	ID          string `json:"id"`
+	ClubID      string `json:"club_id"`
+	UserID      string `json:"user_id"`
+	Image       string `json:"image"`
+	Description string `json:"description"`
+	LikeCount   string `json:"like_count"`
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type CreatePostPayload struct {
+	Image       string `json:"image"`
+	Description string `json:"description"`
+}
@@ -3,3 +3,22 @@ package models
 type Role string
 
 type Permission string
+
+// ClubPermissions is what a user may do in a club right now, for the front
+// end to decide which actions to offer. Roles are the roles held in the
+// club, PlatformRole the platform role that applies on top of them.
+type ClubPermissions struct {
+	ClubID       string       `json:"club_id"`
+	Status       string       `json:"status"`
+	Roles        []string     `json:"roles"`
+	PlatformRole string       `json:"platform_role,omitempty"`
+	Permissions  []Permission `json:"permissions"`
+}
+
+// UserPermissions is ClubPermissions for every club the user is a member
+// of, along with what their platform role allows across clubs.
+type UserPermissions struct {
+	PlatformRole        string            `json:"platform_role,omitempty"`
+	PlatformPermissions []Permission      `json:"platform_permissions"`
+	Clubs               []ClubPermissions `json:"clubs"`
+}
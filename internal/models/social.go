@@ -0,0 +1,64 @@
+package models
+
+const (
+	SocialPostDraft      = "draft"
+	SocialPostScheduled  = "scheduled"
+	SocialPostPublishing = "publishing"
+	SocialPostPublished  = "published"
+	SocialPostFailed     = "failed"
+)
+
+// SocialAccount is an external account of a club. Config holds the
+// connector settings such as URLs and tokens and is never returned by the
+// API.
+type SocialAccount struct {
+	ID        string            `json:"id"`
+	ClubID    string            `json:"club_id"`
+	Platform  string            `json:"platform"`
+	Name      string            `json:"name"`
+	Config    map[string]string `json:"-"`
+	CreatedAt string            `json:"created_at"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+type CreateSocialAccountPayload struct {
+	Platform string            `json:"platform"`
+	Name     string            `json:"name"`
+	Config   map[string]string `json:"config"`
+}
+
+type SocialPost struct {
+	ID          string  `json:"id"`
+	ClubID      string  `json:"club_id"`
+	AccountID   string  `json:"account_id"`
+	UserID      string  `json:"user_id"`
+	EventID     *string `json:"event_id"`
+	Content     string  `json:"content"`
+	Image       string  `json:"image"`
+	Status      string  `json:"status"`
+	ScheduledAt *string `json:"scheduled_at"`
+	PublishedAt *string `json:"published_at"`
+	ExternalID  *string `json:"external_id"`
+	LastError   *string `json:"last_error"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+}
+
+type CreateSocialPostPayload struct {
+	AccountID string `json:"account_id"`
+	Content   string `json:"content"`
+	Image     string `json:"image"`
+}
+
+type UpdateSocialPostPayload struct {
+	Content string `json:"content"`
+	Image   string `json:"image"`
+}
+
+type ScheduleSocialPostPayload struct {
+	ScheduledAt string `json:"scheduled_at"`
+}
+
+type CreateSocialPostFromEventPayload struct {
+	AccountID string `json:"account_id"`
+}
@@ -0,0 +1,21 @@
+package models
+
+const (
+	TrashTypeClub  = "club"
+	TrashTypeEvent = "event"
+	TrashTypePost  = "post"
+)
+
+// TrashItem is a club, event or post that was deleted and can still be
+// restored until it is purged.
+type TrashItem struct {
+	Type      string `json:"type"`
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	DeletedAt string `json:"deleted_at"`
+}
+
+type RestorePayload struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "encoding/json"
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        string   `json:"id"`
+	ClubID    string   `json:"club_id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Topics    []string `json:"topics"`
+	Active    bool     `json:"active"`
+	CreatedAt string   `json:"created_at"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+type CreateWebhookPayload struct {
+	URL    string   `json:"url"`
+	Topics []string `json:"topics"`
+}
+
+type UpdateWebhookPayload struct {
+	URL    string   `json:"url"`
+	Topics []string `json:"topics"`
+	Active bool     `json:"active"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	Topic          string          `json:"topic"`
+	Payload        json.RawMessage `json:"payload,omitempty"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"next_attempt_at"`
+	LastStatusCode *int            `json:"last_status_code"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *string         `json:"delivered_at"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+// PendingWebhookDelivery is a claimed delivery together with the target
+// webhook's URL and signing secret.
+type PendingWebhookDelivery struct {
+	ID       string
+	Topic    string
+	Payload  []byte
+	Attempts int
+	URL      string
+	Secret   string
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"api/pkg/logging"
+)
+
+const (
+	TypeEventReminder    = "event.reminder"
+	TypeClubRoleExpiring = "club.role_expiring"
+)
+
+// Notification is a single message addressed to one user. Channels decide
+// on their own which fields they need, e.g. the email channel skips
+// notifications without an Email.
+type Notification struct {
+	UserID string
+	Email  string
+	ClubID string
+	Type   string
+	Title  string
+	Body   string
+	Data   map[string]string
+}
+
+type Channel interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// Dispatcher fans a notification out to every registered channel.
+type Dispatcher struct {
+	channels []Channel
+}
+
+func NewDispatcher(channels ...Channel) *Dispatcher {
+	return &Dispatcher{
+		channels: channels,
+	}
+}
+
+func (d *Dispatcher) Send(ctx context.Context, n Notification) error {
+	var firstErr error
+	for _, channel := range d.channels {
+		if err := channel.Send(ctx, n); err != nil {
+			err = fmt.Errorf("%s channel: %w", channel.Name(), err)
+			logging.FromContext(ctx).Error("failed to send notification", "user_id", n.UserID, "type", n.Type, "error", err)
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
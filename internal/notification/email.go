@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"strings"
+
+	"api/internal/metrics"
+)
+
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// The email channel is considered disabled when Host is empty.
+func (c EmailConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+type EmailChannel struct {
+	config EmailConfig
+}
+
+func NewEmailChannel(config EmailConfig) *EmailChannel {
+	return &EmailChannel{
+		config: config,
+	}
+}
+
+func (e *EmailChannel) Name() string {
+	return "email"
+}
+
+func (e *EmailChannel) Send(ctx context.Context, n Notification) error {
+	if n.Email == "" {
+		return nil
+	}
+
+	from, err := mail.ParseAddress(e.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(n.Email)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	var auth smtp.Auth
+	if e.config.Username != "" {
+		auth = smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", e.config.Host, e.config.Port)
+	err = smtp.SendMail(addr, auth, from.Address, []string{to.Address}, buildMessage(from, to, n.Title, n.Body))
+	metrics.MailsSent.WithLabelValues(metrics.Result(err)).Inc()
+	return err
+}
+
+// buildMessage builds a plain text mail. Titles come from event and club
+// names chosen by users: line breaks are dropped so they cannot add headers
+// and the subject is encoded as it may hold any character.
+func buildMessage(from, to *mail.Address, subject, body string) []byte {
+	subject = strings.Join(strings.FieldsFunc(subject, func(r rune) bool { return r == '\r' || r == '\n' }), " ")
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from.String() + "\r\n")
+	msg.WriteString("To: " + to.String() + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(body)
+	return []byte(msg.String())
+}
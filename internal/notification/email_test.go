@@ -0,0 +1,40 @@
+package notification
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessage(t *testing.T) {
+	from := &mail.Address{Name: "Community Portal", Address: "portal@example.com"}
+	to := &mail.Address{Address: "member@example.com"}
+
+	tests := []struct {
+		name    string
+		subject string
+		want    string
+	}{
+		{"plain", "Reminder: Chess starts in 1 hour", "Subject: Reminder: Chess starts in 1 hour\r\n"},
+		{"header injection", "Chess\r\nBcc: victim@example.com", "Subject: Chess Bcc: victim@example.com\r\n"},
+		{"bare line feed", "Chess\nBcc: victim@example.com", "Subject: Chess Bcc: victim@example.com\r\n"},
+		{"non-ascii", "Satranç kulübü", "Subject: =?utf-8?q?Satran=C3=A7_kul=C3=BCb=C3=BC?=\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := string(buildMessage(from, to, tt.subject, "body"))
+			headers, _, _ := strings.Cut(msg, "\r\n\r\n")
+			if !strings.Contains(headers+"\r\n", tt.want) {
+				t.Errorf("headers = %q, want them to contain %q", headers, tt.want)
+			}
+			if strings.Contains(headers, "\r\nBcc:") {
+				t.Errorf("headers = %q, the subject added a header", headers)
+			}
+		})
+	}
+
+	msg := string(buildMessage(from, to, "Chess", "body"))
+	if !strings.HasPrefix(msg, "From: \"Community Portal\" <portal@example.com>\r\nTo: <member@example.com>\r\n") {
+		t.Errorf("message = %q, want the addresses formatted by net/mail", msg)
+	}
+}
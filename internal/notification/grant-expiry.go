@@ -0,0 +1,134 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"api/internal/audit"
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+)
+
+const grantBatchSize = 100
+
+type GrantExpiryConfig struct {
+	Notice   time.Duration
+	Interval time.Duration
+}
+
+// GrantExpiryWorker warns members whose time-limited role is about to
+// expire and removes the roles that have expired.
+type GrantExpiryWorker struct {
+	db         *sql.DB
+	config     GrantExpiryConfig
+	dispatcher *Dispatcher
+	bus        *bus.Bus
+}
+
+func NewGrantExpiryWorker(db *sql.DB, config GrantExpiryConfig, dispatcher *Dispatcher, eventBus *bus.Bus) *GrantExpiryWorker {
+	return &GrantExpiryWorker{
+		db:         db,
+		config:     config,
+		dispatcher: dispatcher,
+		bus:        eventBus,
+	}
+}
+
+func (g *GrantExpiryWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.notifyExpiringGrants(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to notify expiring role grants", "error", err)
+		}
+		if err := g.deleteExpiredGrants(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to delete expired role grants", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (g *GrantExpiryWorker) notifyExpiringGrants(ctx context.Context) error {
+	clubUserRepository := repository.NewClubUserRepository(db.WithContext(ctx, g.db))
+
+	for {
+		grants, err := clubUserRepository.ClaimExpiringGrants(time.Now().Add(g.config.Notice), grantBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, grant := range grants {
+			n := Notification{
+				UserID: grant.UserID,
+				ClubID: grant.ClubID,
+				Type:   TypeClubRoleExpiring,
+				Title:  fmt.Sprintf("Your %s role in %s expires soon", grant.Role, grant.ClubName),
+				Body:   fmt.Sprintf("Your %s role in %s expires on %s.", grant.Role, grant.ClubName, grant.ValidUntil.Format(time.RFC1123)),
+				Data:   map[string]string{"club_id": grant.ClubID},
+			}
+			if grant.EmailPreferences {
+				n.Email = grant.Email
+			}
+			g.dispatcher.Send(ctx, n)
+		}
+
+		if len(grants) < grantBatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// deleteExpiredGrants revokes the expired roles and records each of them in
+// the audit log. Members left without a role are announced as removed.
+func (g *GrantExpiryWorker) deleteExpiredGrants(ctx context.Context) error {
+	var grants []models.ExpiringGrant
+	err := repository.RunInTx(ctx, g.db, func(tx *repository.UnitOfWork) error {
+		var err error
+		grants, err = tx.ClubUsers().DeleteExpiredGrants()
+		if err != nil {
+			return err
+		}
+
+		for _, grant := range grants {
+			err := audit.Record(tx, audit.Actor{}, audit.Change{
+				ClubID:     grant.ClubID,
+				Action:     audit.ActionMemberGrantExpired,
+				TargetType: audit.TargetMember,
+				TargetID:   grant.UserID,
+				Before:     models.ClubMemberChange{Role: grant.Role, ValidUntil: &grant.ValidUntil},
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, grant := range grants {
+		topic := bus.ClubMemberRemoved
+		if grant.StillMember {
+			topic = bus.ClubMemberRoleChanged
+		}
+		g.bus.Publish(ctx, bus.Message{
+			Topic:  topic,
+			ClubID: grant.ClubID,
+			UserID: grant.UserID,
+			Data:   models.ClubMemberChange{PreviousRole: grant.Role},
+		})
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/repository"
+	db "api/pkg/database"
+)
+
+// InboxChannel stores notifications in the notifications table so they show
+// up in the user's in-app inbox. Every stored notification is announced on
+// the bus.
+type InboxChannel struct {
+	db  *sql.DB
+	bus *bus.Bus
+}
+
+func NewInboxChannel(db *sql.DB, eventBus *bus.Bus) *InboxChannel {
+	return &InboxChannel{
+		db:  db,
+		bus: eventBus,
+	}
+}
+
+func (i *InboxChannel) Name() string {
+	return "inbox"
+}
+
+func (i *InboxChannel) Send(ctx context.Context, n Notification) error {
+	notification := models.Notification{
+		UserID: n.UserID,
+		ClubID: n.ClubID,
+		Type:   n.Type,
+		Title:  n.Title,
+		Body:   n.Body,
+		Data:   n.Data,
+	}
+
+	notificationRepository := repository.NewNotificationRepository(db.WithContext(ctx, i.db))
+	notificationID, err := notificationRepository.CreateNotification(notification)
+	if err != nil {
+		return err
+	}
+	notification.ID = notificationID
+
+	i.bus.Publish(ctx, bus.Message{
+		Topic:  bus.NotificationCreated,
+		ClubID: n.ClubID,
+		UserID: n.UserID,
+		Data:   notification,
+	})
+	return nil
+}
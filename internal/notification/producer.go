@@ -0,0 +1,208 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"api/internal/bus"
+	"api/internal/models"
+	"api/internal/permissions"
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+)
+
+const (
+	TypeClubMemberAdded       = "club.member_added"
+	TypeClubMemberRemoved     = "club.member_removed"
+	TypeClubMemberRoleChanged = "club.member_role_changed"
+	TypeClubTransferRequested = "club.transfer_requested"
+	TypeClubStatusChanged     = "club.status_changed"
+	TypeEventUpdated          = "event.updated"
+	TypeEventCancelled        = "event.cancelled"
+)
+
+// Producer turns bus messages into notifications for the affected users.
+type Producer struct {
+	db         *sql.DB
+	dispatcher *Dispatcher
+}
+
+func NewProducer(db *sql.DB, dispatcher *Dispatcher) *Producer {
+	return &Producer{
+		db:         db,
+		dispatcher: dispatcher,
+	}
+}
+
+func (p *Producer) Handle(ctx context.Context, msg bus.Message) {
+	var err error
+	switch msg.Topic {
+	case bus.ClubMemberAdded, bus.ClubMemberRemoved, bus.ClubMemberRoleChanged, bus.ClubTransferRequested:
+		err = p.notifyMember(ctx, msg)
+	case bus.EventUpdated, bus.EventCancelled:
+		err = p.notifyAttendees(ctx, msg)
+	case bus.ClubStatusChanged:
+		err = p.notifyOwners(ctx, msg)
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to produce notifications", "topic", msg.Topic, "error", err)
+	}
+}
+
+func (p *Producer) notifyMember(ctx context.Context, msg bus.Message) error {
+	// Users are not notified about changes they made themselves
+	if msg.UserID == "" || msg.UserID == msg.ActorID {
+		return nil
+	}
+
+	change, _ := msg.Data.(models.ClubMemberChange)
+
+	clubRepository := repository.NewClubRepository(db.WithContext(ctx, p.db))
+	club, err := clubRepository.GetClubByID(msg.ClubID)
+	if err != nil {
+		return err
+	}
+	if club == nil {
+		return nil
+	}
+
+	n := Notification{
+		UserID: msg.UserID,
+		ClubID: msg.ClubID,
+		Data:   map[string]string{"club_id": msg.ClubID},
+	}
+
+	switch msg.Topic {
+	case bus.ClubMemberAdded:
+		n.Type = TypeClubMemberAdded
+		n.Title = fmt.Sprintf("You were added to %s", club.Name)
+		n.Body = fmt.Sprintf("You are now %s of %s.", change.Role, club.Name)
+	case bus.ClubMemberRemoved:
+		n.Type = TypeClubMemberRemoved
+		n.Title = fmt.Sprintf("You were removed from %s", club.Name)
+		n.Body = fmt.Sprintf("You are no longer a member of %s.", club.Name)
+	case bus.ClubMemberRoleChanged:
+		n.Type = TypeClubMemberRoleChanged
+		n.Title = fmt.Sprintf("Your role in %s changed", club.Name)
+		switch {
+		case change.PreviousRole == "":
+			n.Body = fmt.Sprintf("You are now also %s of %s.", change.Role, club.Name)
+		case change.Role == "":
+			n.Body = fmt.Sprintf("You are no longer %s of %s.", change.PreviousRole, club.Name)
+		default:
+			n.Body = fmt.Sprintf("Your role in %s changed from %s to %s.", club.Name, change.PreviousRole, change.Role)
+		}
+	case bus.ClubTransferRequested:
+		transfer, _ := msg.Data.(*models.OwnershipTransfer)
+		if transfer == nil {
+			return nil
+		}
+		n.Type = TypeClubTransferRequested
+		n.Title = fmt.Sprintf("You were asked to take over %s", club.Name)
+		n.Body = fmt.Sprintf("Accept the transfer to become owner of %s.", club.Name)
+		n.Data["transfer_id"] = transfer.ID
+	}
+
+	return p.dispatcher.Send(ctx, n)
+}
+
+// notifyOwners tells the owners of a club that platform staff or another
+// owner changed the status of their club.
+func (p *Producer) notifyOwners(ctx context.Context, msg bus.Message) error {
+	change, ok := msg.Data.(models.ClubStatusChange)
+	if !ok {
+		return nil
+	}
+
+	conn := db.WithContext(ctx, p.db)
+	club, err := repository.NewClubRepository(conn).GetClubByID(msg.ClubID)
+	if err != nil {
+		return err
+	}
+	if club == nil {
+		return nil
+	}
+
+	owners, err := repository.NewClubUserRepository(conn).ListUserIDsWithRole(msg.ClubID, permissions.OwnerRole.Name)
+	if err != nil {
+		return err
+	}
+
+	n := Notification{
+		ClubID: msg.ClubID,
+		Type:   TypeClubStatusChanged,
+		Data:   map[string]string{"club_id": msg.ClubID, "status": change.Status},
+	}
+	switch {
+	case change.Status == models.ClubStatusActive && change.PreviousStatus == models.ClubStatusPending:
+		n.Title = fmt.Sprintf("%s was approved", club.Name)
+		n.Body = fmt.Sprintf("%s is now listed and can publish events.", club.Name)
+	case change.Status == models.ClubStatusActive:
+		n.Title = fmt.Sprintf("%s is active again", club.Name)
+		n.Body = fmt.Sprintf("%s is listed again and can publish events.", club.Name)
+	case change.Status == models.ClubStatusRejected:
+		n.Title = fmt.Sprintf("%s was rejected", club.Name)
+		n.Body = fmt.Sprintf("%s was not approved: %s", club.Name, change.Reason)
+	case change.Status == models.ClubStatusSuspended:
+		n.Title = fmt.Sprintf("%s was suspended", club.Name)
+		n.Body = fmt.Sprintf("%s was suspended by the platform staff.", club.Name)
+		if change.Reason != "" {
+			n.Body = fmt.Sprintf("%s was suspended: %s", club.Name, change.Reason)
+		}
+	case change.Status == models.ClubStatusArchived:
+		n.Title = fmt.Sprintf("%s was archived", club.Name)
+		n.Body = fmt.Sprintf("%s is no longer listed and cannot publish events.", club.Name)
+	default:
+		return nil
+	}
+
+	for _, owner := range owners {
+		if owner == msg.ActorID {
+			continue
+		}
+		n.UserID = owner
+		p.dispatcher.Send(ctx, n)
+	}
+
+	return nil
+}
+
+func (p *Producer) notifyAttendees(ctx context.Context, msg bus.Message) error {
+	event, ok := msg.Data.(*models.Event)
+	if !ok {
+		return nil
+	}
+
+	eventRepository := repository.NewEventRepository(db.WithContext(ctx, p.db))
+	attendees, err := eventRepository.GetEventAttendees(event.ID)
+	if err != nil {
+		return err
+	}
+
+	n := Notification{
+		ClubID: event.ClubID,
+		Data:   map[string]string{"event_id": event.ID},
+	}
+	if msg.Topic == bus.EventCancelled {
+		n.Type = TypeEventCancelled
+		n.Title = fmt.Sprintf("%s was cancelled", event.Title)
+		n.Body = fmt.Sprintf("%s scheduled for %s was cancelled.", event.Title, event.StartDate)
+	} else {
+		n.Type = TypeEventUpdated
+		n.Title = fmt.Sprintf("%s was updated", event.Title)
+		n.Body = fmt.Sprintf("%s now takes place at %s in %s.", event.Title, event.StartDate, event.Location)
+	}
+
+	for _, attendee := range attendees {
+		if attendee.UserID == msg.ActorID {
+			continue
+		}
+		n.UserID = attendee.UserID
+		p.dispatcher.Send(ctx, n)
+	}
+
+	return nil
+}
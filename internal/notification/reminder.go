@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+)
+
+const (
+	reminderBatchSize = 100
+	// Reminders not marked as sent within the lease are sent again, to the
+	// attendees who already got them as well.
+	reminderLeaseTime = 5 * time.Minute
+)
+
+type ReminderConfig struct {
+	Offsets  []time.Duration
+	Interval time.Duration
+}
+
+// ReminderScheduler keeps the event_reminders table in sync with the events
+// and periodically sends the reminders that are due to the event attendees.
+type ReminderScheduler struct {
+	db         *sql.DB
+	config     ReminderConfig
+	dispatcher *Dispatcher
+}
+
+func NewReminderScheduler(db *sql.DB, config ReminderConfig, dispatcher *Dispatcher) *ReminderScheduler {
+	return &ReminderScheduler{
+		db:         db,
+		config:     config,
+		dispatcher: dispatcher,
+	}
+}
+
+// Schedule (re)creates the pending reminders of an event from its current
+// start date. It is called whenever an event is created or updated.
+func (s *ReminderScheduler) Schedule(ctx context.Context, eventID string) error {
+	offsetMinutes := make([]int, 0, len(s.config.Offsets))
+	for _, offset := range s.config.Offsets {
+		offsetMinutes = append(offsetMinutes, int(offset/time.Minute))
+	}
+
+	reminderRepository := repository.NewEventReminderRepository(db.WithContext(ctx, s.db))
+	return reminderRepository.ScheduleReminders(eventID, offsetMinutes)
+}
+
+// Cancel drops the pending reminders of an event.
+func (s *ReminderScheduler) Cancel(ctx context.Context, eventID string) error {
+	reminderRepository := repository.NewEventReminderRepository(db.WithContext(ctx, s.db))
+	return reminderRepository.DeletePendingReminders(eventID)
+}
+
+func (s *ReminderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sendDueReminders(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to send event reminders", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *ReminderScheduler) sendDueReminders(ctx context.Context) error {
+	// Reminders already dispatched are marked as sent even while shutting
+	// down, otherwise they would be sent again once their lease expires.
+	session := db.WithContext(context.WithoutCancel(ctx), s.db)
+	reminderRepository := repository.NewEventReminderRepository(session)
+	eventRepository := repository.NewEventRepository(session)
+
+	for {
+		reminders, err := reminderRepository.ClaimDueReminders(reminderBatchSize, reminderLeaseTime)
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			// Reminders left unsent are claimed again once their lease expires
+			if ctx.Err() != nil {
+				return nil
+			}
+			attendees, err := eventRepository.GetEventAttendees(reminder.EventID)
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to get attendees of event", "event_id", reminder.EventID, "error", err)
+				continue
+			}
+
+			title := fmt.Sprintf("Reminder: %s starts in %s", reminder.Title, formatOffset(reminder.OffsetMinutes))
+			body := fmt.Sprintf("%s starts at %s.", reminder.Title, reminder.StartDate)
+			if reminder.Location != "" {
+				body = fmt.Sprintf("%s starts at %s in %s.", reminder.Title, reminder.StartDate, reminder.Location)
+			}
+
+			for _, attendee := range attendees {
+				n := Notification{
+					UserID: attendee.UserID,
+					ClubID: reminder.ClubID,
+					Type:   TypeEventReminder,
+					Title:  title,
+					Body:   body,
+					Data:   map[string]string{"event_id": reminder.EventID},
+				}
+				// Users who opted out of emails still get the in-app notification
+				if attendee.EmailPreferences {
+					n.Email = attendee.Email
+				}
+				s.dispatcher.Send(ctx, n)
+			}
+
+			if err := reminderRepository.MarkReminderSent(reminder.ID); err != nil {
+				logging.FromContext(ctx).Error("failed to mark event reminder as sent", "reminder_id", reminder.ID, "error", err)
+			}
+		}
+
+		if len(reminders) < reminderBatchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func formatOffset(minutes int) string {
+	offset := time.Duration(minutes) * time.Minute
+	if offset%time.Hour == 0 {
+		hours := int(offset / time.Hour)
+		if hours == 1 {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", hours)
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
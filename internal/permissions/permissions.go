@@ -1,5 +1,11 @@
 package permissions
 
+import (
+	"errors"
+	"slices"
+	"strings"
+)
+
 type Permission string
 
 const (
@@ -23,18 +29,68 @@ const (
 	DeleteClubUser              Permission = "user:delete"
 	UpdateClubUser              Permission = "user:update"
 	ReadClubUser                Permission = "user:read"
+	AuditReadPermission         Permission = "audit:read"
 )
 
 type Permissions map[Permission]bool
 
+// List returns the granted permissions in a stable order.
+func (p Permissions) List() []Permission {
+	list := make([]Permission, 0, len(p))
+	for permission, granted := range p {
+		if granted {
+			list = append(list, permission)
+		}
+	}
+	slices.Sort(list)
+	return list
+}
+
+// AllPermissions lists every club permission, in the order they are
+// declared.
+var AllPermissions = []Permission{
+	SocialMediaReadPermission,
+	SocialMediaWritePermission,
+	SocialMediaDeletePermission,
+	SocialMediaUpdatePermission,
+	MailReadPermission,
+	MailWritePermission,
+	MailDeletePermission,
+	MailUpdatePermission,
+	ClubReadPermission,
+	ClubWritePermission,
+	ClubDeletePermission,
+	ClubUpdatePermission,
+	EventReadPermission,
+	EventWritePermission,
+	EventDeletePermission,
+	EventUpdatePermission,
+	AddClubUser,
+	DeleteClubUser,
+	UpdateClubUser,
+	ReadClubUser,
+	AuditReadPermission,
+}
+
+// Role ranks order the roles of a club. Members can only grant, revoke and
+// manage roles ranked strictly below their own.
+const (
+	MemberRank = iota + 1
+	ManagerRank
+	AdminRank
+	OwnerRank
+)
+
 type Role struct {
 	Name        string
+	Rank        int
 	Permissions Permissions
 }
 
 var (
 	AdminRole = Role{
 		Name: "admin",
+		Rank: AdminRank,
 		Permissions: Permissions{
 			SocialMediaDeletePermission: true,
 			SocialMediaReadPermission:   true,
@@ -56,10 +112,12 @@ var (
 			DeleteClubUser:              true,
 			UpdateClubUser:              true,
 			ReadClubUser:                true,
+			AuditReadPermission:         true,
 		},
 	}
 	OwnerRole = Role{
 		Name: "owner",
+		Rank: OwnerRank,
 		Permissions: Permissions{
 			SocialMediaDeletePermission: true,
 			SocialMediaReadPermission:   true,
@@ -81,10 +139,12 @@ var (
 			DeleteClubUser:              true,
 			UpdateClubUser:              true,
 			ReadClubUser:                true,
+			AuditReadPermission:         true,
 		},
 	}
 	SocialAdminRole = Role{
 		Name: "social_admin",
+		Rank: ManagerRank,
 		Permissions: Permissions{
 			SocialMediaDeletePermission: true,
 			SocialMediaReadPermission:   true,
@@ -94,6 +154,7 @@ var (
 	}
 	MailAdminRole = Role{
 		Name: "mail_admin",
+		Rank: ManagerRank,
 		Permissions: Permissions{
 			MailDeletePermission: true,
 			MailReadPermission:   true,
@@ -103,6 +164,7 @@ var (
 	}
 	ClubAdminRole = Role{
 		Name: "club_admin",
+		Rank: ManagerRank,
 		Permissions: Permissions{
 			ClubDeletePermission: true,
 			ClubReadPermission:   true,
@@ -110,12 +172,71 @@ var (
 			ClubWritePermission:  true,
 		},
 	}
+	MemberRole = Role{
+		Name:        "member",
+		Rank:        MemberRank,
+		Permissions: Permissions{},
+	}
+)
+
+// Reasons CanManage refuses a change, meant to be shown to the caller.
+var (
+	ErrRoleNotBelow   = errors.New("you can only grant or revoke roles ranked below your own")
+	ErrMemberNotBelow = errors.New("you can only manage members ranked below you")
 )
 
 func (r *Role) HasPermission(p Permission) bool {
 	return r.Permissions[p]
 }
 
+// Combine returns the role of a member holding all of roles: it grants the
+// union of their permissions and ranks as the highest of them. A single role
+// is returned as is, nil roles are skipped and Combine returns nil when no
+// role is left.
+func Combine(roles ...*Role) *Role {
+	var held []*Role
+	for _, role := range roles {
+		if role != nil {
+			held = append(held, role)
+		}
+	}
+	switch len(held) {
+	case 0:
+		return nil
+	case 1:
+		return held[0]
+	}
+
+	combined := &Role{Permissions: Permissions{}}
+	names := make([]string, 0, len(held))
+	for _, role := range held {
+		names = append(names, role.Name)
+		combined.Rank = max(combined.Rank, role.Rank)
+		for permission, granted := range role.Permissions {
+			if granted {
+				combined.Permissions[permission] = true
+			}
+		}
+	}
+	combined.Name = strings.Join(names, "+")
+	return combined
+}
+
+// CanManage reports whether a member with role actor may change the
+// membership of a member holding target and give them role. target is nil
+// for users who are not a member yet and role is nil when the member is
+// removed. Owners are never managed this way, ownership is handed over with
+// a transfer.
+func CanManage(actor, target, role *Role) error {
+	if target != nil && target.Rank >= actor.Rank {
+		return ErrMemberNotBelow
+	}
+	if role != nil && role.Rank >= actor.Rank {
+		return ErrRoleNotBelow
+	}
+	return nil
+}
+
 func GetRoleWithRoleName(roleName string) *Role {
 	switch roleName {
 	case "owner":
@@ -128,6 +249,8 @@ func GetRoleWithRoleName(roleName string) *Role {
 		return &MailAdminRole
 	case "club_admin":
 		return &ClubAdminRole
+	case "member":
+		return &MemberRole
 	default:
 		return nil
 	}
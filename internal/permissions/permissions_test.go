@@ -0,0 +1,168 @@
+package permissions
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestCanManage(t *testing.T) {
+	tests := []struct {
+		name   string
+		actor  *Role
+		target *Role
+		role   *Role
+		want   error
+	}{
+		// Adding users
+		{"owner adds admin", &OwnerRole, nil, &AdminRole, nil},
+		{"owner adds club admin", &OwnerRole, nil, &ClubAdminRole, nil},
+		{"owner adds member", &OwnerRole, nil, &MemberRole, nil},
+		{"owner adds owner", &OwnerRole, nil, &OwnerRole, ErrRoleNotBelow},
+		{"admin adds mail admin", &AdminRole, nil, &MailAdminRole, nil},
+		{"admin adds social admin", &AdminRole, nil, &SocialAdminRole, nil},
+		{"admin adds member", &AdminRole, nil, &MemberRole, nil},
+		{"admin adds admin", &AdminRole, nil, &AdminRole, ErrRoleNotBelow},
+		{"admin adds owner", &AdminRole, nil, &OwnerRole, ErrRoleNotBelow},
+		{"club admin adds member", &ClubAdminRole, nil, &MemberRole, nil},
+		{"club admin adds mail admin", &ClubAdminRole, nil, &MailAdminRole, ErrRoleNotBelow},
+		{"member adds member", &MemberRole, nil, &MemberRole, ErrRoleNotBelow},
+
+		// Changing roles
+		{"owner promotes member to admin", &OwnerRole, &MemberRole, &AdminRole, nil},
+		{"owner demotes admin to member", &OwnerRole, &AdminRole, &MemberRole, nil},
+		{"owner promotes admin to owner", &OwnerRole, &AdminRole, &OwnerRole, ErrRoleNotBelow},
+		{"owner demotes owner", &OwnerRole, &OwnerRole, &AdminRole, ErrMemberNotBelow},
+		{"admin promotes member to club admin", &AdminRole, &MemberRole, &ClubAdminRole, nil},
+		{"admin demotes mail admin to member", &AdminRole, &MailAdminRole, &MemberRole, nil},
+		{"admin promotes member to admin", &AdminRole, &MemberRole, &AdminRole, ErrRoleNotBelow},
+		{"admin demotes admin", &AdminRole, &AdminRole, &MemberRole, ErrMemberNotBelow},
+		{"admin demotes owner", &AdminRole, &OwnerRole, &MemberRole, ErrMemberNotBelow},
+		{"admin changes own rank", &AdminRole, &AdminRole, &ClubAdminRole, ErrMemberNotBelow},
+		{"club admin changes social admin", &ClubAdminRole, &SocialAdminRole, &MemberRole, ErrMemberNotBelow},
+
+		// Removing members
+		{"owner removes admin", &OwnerRole, &AdminRole, nil, nil},
+		{"owner removes member", &OwnerRole, &MemberRole, nil, nil},
+		{"owner removes owner", &OwnerRole, &OwnerRole, nil, ErrMemberNotBelow},
+		{"admin removes social admin", &AdminRole, &SocialAdminRole, nil, nil},
+		{"admin removes member", &AdminRole, &MemberRole, nil, nil},
+		{"admin removes admin", &AdminRole, &AdminRole, nil, ErrMemberNotBelow},
+		{"admin removes owner", &AdminRole, &OwnerRole, nil, ErrMemberNotBelow},
+		{"mail admin removes member", &MailAdminRole, &MemberRole, nil, nil},
+		{"mail admin removes club admin", &MailAdminRole, &ClubAdminRole, nil, ErrMemberNotBelow},
+		{"member removes member", &MemberRole, &MemberRole, nil, ErrMemberNotBelow},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanManage(tt.actor, tt.target, tt.role); !errors.Is(got, tt.want) {
+				t.Errorf("CanManage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRolesAreRanked(t *testing.T) {
+	tests := []struct {
+		name string
+		rank int
+	}{
+		{"owner", OwnerRank},
+		{"admin", AdminRank},
+		{"club_admin", ManagerRank},
+		{"mail_admin", ManagerRank},
+		{"social_admin", ManagerRank},
+		{"member", MemberRank},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role := GetRoleWithRoleName(tt.name)
+			if role == nil {
+				t.Fatalf("GetRoleWithRoleName(%q) = nil", tt.name)
+			}
+			if role.Rank != tt.rank {
+				t.Errorf("rank of %s = %d, want %d", tt.name, role.Rank, tt.rank)
+			}
+		})
+	}
+
+	if GetRoleWithRoleName("superuser") != nil {
+		t.Error("unknown role names must not resolve to a role")
+	}
+}
+
+func TestCombine(t *testing.T) {
+	if Combine() != nil || Combine(nil) != nil {
+		t.Error("Combine without roles must return nil")
+	}
+	if got := Combine(&MailAdminRole); got != &MailAdminRole {
+		t.Errorf("Combine(mail_admin) = %v, want the mail_admin role itself", got)
+	}
+
+	role := Combine(&MailAdminRole, &SocialAdminRole)
+	for _, permission := range []Permission{MailWritePermission, SocialMediaWritePermission} {
+		if !role.HasPermission(permission) {
+			t.Errorf("mail_admin+social_admin lacks %s", permission)
+		}
+	}
+	if role.HasPermission(ClubUpdatePermission) {
+		t.Errorf("mail_admin+social_admin must not grant %s", ClubUpdatePermission)
+	}
+	if role.Rank != ManagerRank {
+		t.Errorf("rank of mail_admin+social_admin = %d, want %d", role.Rank, ManagerRank)
+	}
+	if role.Name != "mail_admin+social_admin" {
+		t.Errorf("name = %q, want %q", role.Name, "mail_admin+social_admin")
+	}
+
+	if got := Combine(&MemberRole, &OwnerRole); got.Rank != OwnerRank {
+		t.Errorf("rank of member+owner = %d, want %d", got.Rank, OwnerRank)
+	}
+	if MailAdminRole.HasPermission(SocialMediaWritePermission) {
+		t.Error("Combine must not change the roles it combines")
+	}
+}
+
+func TestPlatformRolesExtendClubRoles(t *testing.T) {
+	for _, permission := range []Permission{ClubDeletePermission, AddClubUser, AuditReadPermission} {
+		if !Combine(nil, SuperadminRole.Club).HasPermission(permission) {
+			t.Errorf("superadmin lacks %s in clubs they are not a member of", permission)
+		}
+	}
+	if Combine(&MemberRole, SuperadminRole.Club).Rank != OwnerRank {
+		t.Error("superadmins must rank as owners in every club")
+	}
+
+	for _, permission := range []Permission{ClubWritePermission, ClubUpdatePermission, EventWritePermission, MailWritePermission, SocialMediaWritePermission, AddClubUser} {
+		if AuditorRole.Club.HasPermission(permission) {
+			t.Errorf("auditors must not be granted %s", permission)
+		}
+	}
+	if AuditorRole.HasPermission(PlatformClubsSuspendPermission) {
+		t.Error("auditors must not suspend clubs")
+	}
+
+	role := Combine(&MailAdminRole, ModeratorRole.Club)
+	if !role.HasPermission(MailWritePermission) || !role.HasPermission(EventDeletePermission) {
+		t.Error("moderator permissions must add to the club roles of the moderator")
+	}
+	for _, permission := range []Permission{ClubUpdatePermission, ClubWritePermission, EventWritePermission, SocialMediaWritePermission} {
+		if ModeratorRole.Club.HasPermission(permission) {
+			t.Errorf("moderators must not be granted %s", permission)
+		}
+	}
+}
+
+func TestPermissionsList(t *testing.T) {
+	got := Permissions{EventWritePermission: true, ClubReadPermission: true, MailReadPermission: false}.List()
+	want := []Permission{ClubReadPermission, EventWritePermission}
+	if !slices.Equal(got, want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	if got := MemberRole.Permissions.List(); got == nil || len(got) != 0 {
+		t.Errorf("List() of no permissions = %#v, want an empty list", got)
+	}
+}
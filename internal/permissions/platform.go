@@ -0,0 +1,120 @@
+package permissions
+
+import "slices"
+
+type PlatformPermission string
+
+const (
+	PlatformClubsReadPermission    PlatformPermission = "platform:clubs:read"
+	PlatformClubsSuspendPermission PlatformPermission = "platform:clubs:suspend"
+	PlatformClubsReviewPermission  PlatformPermission = "platform:clubs:review"
+	PlatformClubsVerifyPermission  PlatformPermission = "platform:clubs:verify"
+	PlatformUsersSuspendPermission PlatformPermission = "platform:users:suspend"
+	PlatformStatsReadPermission    PlatformPermission = "platform:stats:read"
+)
+
+type PlatformPermissions map[PlatformPermission]bool
+
+// List returns the granted permissions in a stable order.
+func (p PlatformPermissions) List() []PlatformPermission {
+	list := make([]PlatformPermission, 0, len(p))
+	for permission, granted := range p {
+		if granted {
+			list = append(list, permission)
+		}
+	}
+	slices.Sort(list)
+	return list
+}
+
+// PlatformRole is held by university staff independently of any club. Club
+// is the role it grants in every club, on top of the roles held there.
+type PlatformRole struct {
+	Name        string
+	Permissions PlatformPermissions
+	Club        *Role
+}
+
+var (
+	SuperadminRole = PlatformRole{
+		Name: "superadmin",
+		Permissions: PlatformPermissions{
+			PlatformClubsReadPermission:    true,
+			PlatformClubsSuspendPermission: true,
+			PlatformClubsReviewPermission:  true,
+			PlatformClubsVerifyPermission:  true,
+			PlatformUsersSuspendPermission: true,
+			PlatformStatsReadPermission:    true,
+		},
+		// Superadmins bypass the club checks, owner-only actions included
+		Club: &Role{
+			Name:        "superadmin",
+			Rank:        OwnerRank,
+			Permissions: OwnerRole.Permissions,
+		},
+	}
+	ModeratorRole = PlatformRole{
+		Name: "moderator",
+		Permissions: PlatformPermissions{
+			PlatformClubsReadPermission:    true,
+			PlatformClubsSuspendPermission: true,
+			PlatformClubsReviewPermission:  true,
+			PlatformClubsVerifyPermission:  true,
+			PlatformUsersSuspendPermission: true,
+			PlatformStatsReadPermission:    true,
+		},
+		// Moderators can read and take down content but not run a club.
+		// club:delete lets them take down feed posts, the other routes it
+		// guards are reserved to owners.
+		Club: &Role{
+			Name: "moderator",
+			Rank: MemberRank,
+			Permissions: Permissions{
+				ClubReadPermission:          true,
+				ClubDeletePermission:        true,
+				EventReadPermission:         true,
+				EventDeletePermission:       true,
+				SocialMediaReadPermission:   true,
+				SocialMediaDeletePermission: true,
+				ReadClubUser:                true,
+				AuditReadPermission:         true,
+			},
+		},
+	}
+	AuditorRole = PlatformRole{
+		Name: "auditor",
+		Permissions: PlatformPermissions{
+			PlatformClubsReadPermission: true,
+			PlatformStatsReadPermission: true,
+		},
+		Club: &Role{
+			Name: "auditor",
+			Rank: MemberRank,
+			Permissions: Permissions{
+				ClubReadPermission:        true,
+				EventReadPermission:       true,
+				MailReadPermission:        true,
+				SocialMediaReadPermission: true,
+				ReadClubUser:              true,
+				AuditReadPermission:       true,
+			},
+		},
+	}
+)
+
+func (r *PlatformRole) HasPermission(p PlatformPermission) bool {
+	return r.Permissions[p]
+}
+
+func GetPlatformRoleWithName(roleName string) *PlatformRole {
+	switch roleName {
+	case "superadmin":
+		return &SuperadminRole
+	case "moderator":
+		return &ModeratorRole
+	case "auditor":
+		return &AuditorRole
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,233 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// attributes are the paths conditions and reasons may refer to. Every value
+// is a list of strings: single values are a list of one, unset values an
+// empty list.
+var attributes = map[string]func(r *Request) []string{
+	"actor.id":            func(r *Request) []string { return value(r.Actor.ID) },
+	"actor.roles":         func(r *Request) []string { return r.Actor.Roles },
+	"actor.platform_role": func(r *Request) []string { return value(r.Actor.PlatformRole) },
+	"club.id":             func(r *Request) []string { return value(r.Club.ID) },
+	"club.status":         func(r *Request) []string { return value(r.Club.Status) },
+	"resource.type":       func(r *Request) []string { return value(r.resource().Type) },
+	"resource.id":         func(r *Request) []string { return value(r.resource().ID) },
+	"resource.club":       func(r *Request) []string { return value(r.resource().Club) },
+	"resource.owner":      func(r *Request) []string { return value(r.resource().Owner) },
+	"resource.status":     func(r *Request) []string { return value(r.resource().Status) },
+	"request.route":       func(r *Request) []string { return value(r.Route) },
+}
+
+func value(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+type operator string
+
+const (
+	opEquals    operator = "=="
+	opNotEquals operator = "!="
+	opIn        operator = "in"
+	opNotIn     operator = "not in"
+)
+
+// operand is either an attribute or a literal list of strings.
+type operand struct {
+	attribute string
+	literal   []string
+}
+
+func (o operand) resolve(r *Request) []string {
+	if o.attribute != "" {
+		return attributes[o.attribute](r)
+	}
+	return o.literal
+}
+
+// condition compares two operands:
+//
+//	a == b      a and b hold the same values, in any order
+//	a != b      they do not
+//	a in b      a is not empty and all of its values are in b
+//	a not in b  none of the values of a are in b
+//
+// Operands are attributes such as actor.id, quoted strings such as 'active'
+// or lists of quoted strings such as ['admin', 'owner'], [] being the empty
+// list that unset attributes are equal to.
+type condition struct {
+	source string
+	left   operand
+	op     operator
+	right  operand
+}
+
+func (c condition) holds(r *Request) bool {
+	left, right := c.left.resolve(r), c.right.resolve(r)
+	switch c.op {
+	case opEquals:
+		return sameValues(left, right)
+	case opNotEquals:
+		return !sameValues(left, right)
+	case opIn:
+		if len(left) == 0 {
+			return false
+		}
+		for _, v := range left {
+			if !slices.Contains(right, v) {
+				return false
+			}
+		}
+		return true
+	case opNotIn:
+		for _, v := range left {
+			if slices.Contains(right, v) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func sameValues(a, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(slices.Compact(a), slices.Compact(b))
+}
+
+func parseCondition(source string) (condition, error) {
+	p := &parser{tokens: tokenize(source)}
+
+	left, err := p.operand()
+	if err != nil {
+		return condition{}, err
+	}
+	op, err := p.operator()
+	if err != nil {
+		return condition{}, err
+	}
+	right, err := p.operand()
+	if err != nil {
+		return condition{}, err
+	}
+	if tok := p.next(); tok != "" {
+		return condition{}, fmt.Errorf("unexpected %q after the condition", tok)
+	}
+
+	return condition{source: source, left: left, op: op, right: right}, nil
+}
+
+// tokenize splits a condition into attributes, quoted strings, operators and
+// the punctuation of lists. Quoted strings keep their quotes.
+func tokenize(source string) []string {
+	var tokens []string
+	for i := 0; i < len(source); {
+		switch c := source[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '[' || c == ']' || c == ',':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '\'':
+			end := strings.IndexByte(source[i+1:], '\'')
+			if end < 0 {
+				tokens = append(tokens, source[i:])
+				return tokens
+			}
+			tokens = append(tokens, source[i:i+end+2])
+			i += end + 2
+		default:
+			end := strings.IndexAny(source[i:], " \t[],'")
+			if end < 0 {
+				end = len(source) - i
+			}
+			tokens = append(tokens, source[i:i+end])
+			i += end
+		}
+	}
+	return tokens
+}
+
+type parser struct {
+	tokens []string
+}
+
+func (p *parser) next() string {
+	if len(p.tokens) == 0 {
+		return ""
+	}
+	tok := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return tok
+}
+
+func (p *parser) operator() (operator, error) {
+	switch tok := p.next(); tok {
+	case "==", "!=", "in":
+		return operator(tok), nil
+	case "not":
+		if p.next() == "in" {
+			return opNotIn, nil
+		}
+		return "", fmt.Errorf(`expected "in" after "not"`)
+	case "":
+		return "", fmt.Errorf("missing operator")
+	default:
+		return "", fmt.Errorf("unknown operator %q, expected ==, !=, in or not in", tok)
+	}
+}
+
+func (p *parser) operand() (operand, error) {
+	switch tok := p.next(); {
+	case tok == "":
+		return operand{}, fmt.Errorf("missing operand")
+	case tok == "[":
+		list := []string{}
+		for {
+			item := p.next()
+			if item == "]" && len(list) == 0 {
+				return operand{literal: list}, nil
+			}
+			s, err := unquote(item)
+			if err != nil {
+				return operand{}, err
+			}
+			list = append(list, s)
+
+			switch sep := p.next(); sep {
+			case ",":
+			case "]":
+				return operand{literal: list}, nil
+			default:
+				return operand{}, fmt.Errorf("expected , or ] in list, got %q", sep)
+			}
+		}
+	case strings.HasPrefix(tok, "'"):
+		s, err := unquote(tok)
+		if err != nil {
+			return operand{}, err
+		}
+		return operand{literal: value(s)}, nil
+	default:
+		if _, ok := attributes[tok]; !ok {
+			return operand{}, fmt.Errorf("unknown attribute %q", tok)
+		}
+		return operand{attribute: tok}, nil
+	}
+}
+
+func unquote(tok string) (string, error) {
+	if len(tok) < 2 || !strings.HasPrefix(tok, "'") || !strings.HasSuffix(tok, "'") {
+		return "", fmt.Errorf("expected a quoted string, got %q", tok)
+	}
+	return tok[1 : len(tok)-1], nil
+}
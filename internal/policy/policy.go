@@ -0,0 +1,310 @@
+// Package policy decides whether a club member may do something by
+// combining their roles with attributes of the actor, the club and the
+// resource they act on. Rules that a role alone cannot express, such as
+// "creators may edit their own event", are declared as data in a versioned
+// policy file loaded at startup.
+package policy
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"api/internal/permissions"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the version of the policy file format this package reads.
+const Version = 1
+
+//go:embed policies.yaml
+var defaultPolicies []byte
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// Any matches every permission in the permissions of a policy.
+const Any = "*"
+
+// Actor is the user asking for a permission.
+type Actor struct {
+	ID string
+	// Roles held in the club right now
+	Roles        []string
+	PlatformRole string
+}
+
+// Club is the club a permission is asked for.
+type Club struct {
+	ID     string
+	Status string
+}
+
+// Resource is what the actor acts on within the club, e.g. the event given
+// in the event-id header.
+type Resource struct {
+	Type   string
+	ID     string
+	Club   string
+	Owner  string
+	Status string
+}
+
+// Request is a permission asked for by an actor. Role is the union of the
+// roles of the actor in the club and of their platform role, nil when they
+// hold none. Resource is nil when the actor acts on the club itself. Route
+// is the path template of the endpoint called, e.g. /api/club/restore,
+// empty outside of a request.
+type Request struct {
+	Actor      Actor
+	Club       Club
+	Resource   *Resource
+	Role       *permissions.Role
+	Permission permissions.Permission
+	Route      string
+}
+
+func (r *Request) resource() *Resource {
+	if r.Resource == nil {
+		return &Resource{}
+	}
+	return r.Resource
+}
+
+// Decision is the outcome of a request. Policy names the policy that
+// decided it, empty when the role did. Reason explains a refusal to the
+// caller.
+type Decision struct {
+	Allowed bool
+	Policy  string
+	Reason  string
+}
+
+type Policy struct {
+	Name        string
+	Description string
+	Effect      string
+	Permissions []string
+	When        []condition
+	Reason      string
+}
+
+func (p *Policy) applies(r *Request) bool {
+	if !p.covers(r.Permission) {
+		return false
+	}
+	for _, c := range p.When {
+		if !c.holds(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) covers(permission permissions.Permission) bool {
+	for _, covered := range p.Permissions {
+		if covered == Any || permissions.Permission(covered) == permission {
+			return true
+		}
+	}
+	return false
+}
+
+var placeholder = regexp.MustCompile(`\{([a-z_.]+)\}`)
+
+// reason fills the {attribute} placeholders of the reason of the policy.
+func (p *Policy) reason(r *Request) string {
+	return placeholder.ReplaceAllStringFunc(p.Reason, func(match string) string {
+		return strings.Join(attributes[match[1:len(match)-1]](r), ", ")
+	})
+}
+
+// Engine evaluates requests against a set of policies.
+type Engine struct {
+	policies []Policy
+}
+
+// Evaluate decides a request. Deny policies are checked first and refuse
+// the request whatever the roles of the actor. Otherwise the request is
+// allowed when the role grants the permission or an allow policy applies.
+func (e *Engine) Evaluate(r Request) Decision {
+	for i := range e.policies {
+		p := &e.policies[i]
+		if p.Effect == EffectDeny && p.applies(&r) {
+			return Decision{Policy: p.Name, Reason: p.reason(&r)}
+		}
+	}
+
+	if r.Role != nil && r.Role.HasPermission(r.Permission) {
+		return Decision{Allowed: true}
+	}
+
+	for i := range e.policies {
+		p := &e.policies[i]
+		if p.Effect == EffectAllow && p.applies(&r) {
+			return Decision{Allowed: true, Policy: p.Name}
+		}
+	}
+
+	roleName := "member"
+	if r.Role != nil && r.Role.Name != "" {
+		roleName = r.Role.Name
+	}
+	return Decision{Reason: fmt.Sprintf("the %s role does not grant %s", roleName, r.Permission)}
+}
+
+// Granted returns the permissions of the request allowed on the club
+// itself, whatever its Permission and Resource.
+func (e *Engine) Granted(r Request) []permissions.Permission {
+	r.Resource = nil
+
+	granted := []permissions.Permission{}
+	for _, permission := range permissions.AllPermissions {
+		r.Permission = permission
+		if e.Evaluate(r).Allowed {
+			granted = append(granted, permission)
+		}
+	}
+	return granted
+}
+
+// Policies returns the loaded policies in the order they are declared.
+func (e *Engine) Policies() []Policy {
+	return e.policies
+}
+
+// Load reads the policy file at path, the policies built into the server
+// when path is empty.
+func Load(path string) (*Engine, error) {
+	if path == "" {
+		return Parse(defaultPolicies)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %w", err)
+	}
+	engine, err := Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return engine, nil
+}
+
+type file struct {
+	Version  int          `yaml:"version"`
+	Policies []policyFile `yaml:"policies"`
+}
+
+type policyFile struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Effect      string   `yaml:"effect"`
+	Permissions []string `yaml:"permissions"`
+	When        []string `yaml:"when"`
+	Reason      string   `yaml:"reason"`
+}
+
+// ValidationError lists everything wrong with a policy file.
+type ValidationError struct {
+	Problems []string
+}
+
+func (v *ValidationError) Error() string {
+	return "invalid policy file:\n  - " + strings.Join(v.Problems, "\n  - ")
+}
+
+// Parse reads and validates a policy file.
+func Parse(content []byte) (*Engine, error) {
+	var f file
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse policy file: %w", err)
+	}
+
+	var problems []string
+	if f.Version != Version {
+		problems = append(problems, fmt.Sprintf("version %d is not supported, expected %d", f.Version, Version))
+	}
+
+	known := map[string]bool{Any: true}
+	for _, permission := range permissions.AllPermissions {
+		known[string(permission)] = true
+	}
+
+	engine := &Engine{}
+	names := map[string]bool{}
+	for i, pf := range f.Policies {
+		name := pf.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+			problems = append(problems, fmt.Sprintf("policy %s has no name", name))
+		} else if names[name] {
+			problems = append(problems, fmt.Sprintf("policy %s is declared twice", name))
+		}
+		names[name] = true
+
+		p := Policy{
+			Name:        pf.Name,
+			Description: pf.Description,
+			Effect:      pf.Effect,
+			Permissions: pf.Permissions,
+			Reason:      pf.Reason,
+		}
+
+		switch p.Effect {
+		case EffectAllow:
+			// An allow policy without conditions would grant the
+			// permission to every member
+			if len(pf.When) == 0 {
+				problems = append(problems, fmt.Sprintf("policy %s allows without conditions", name))
+			}
+		case EffectDeny:
+			if p.Reason == "" {
+				problems = append(problems, fmt.Sprintf("policy %s denies without a reason", name))
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("policy %s: effect %q must be allow or deny", name, p.Effect))
+		}
+
+		if len(p.Permissions) == 0 {
+			problems = append(problems, fmt.Sprintf("policy %s covers no permissions", name))
+		}
+		for _, permission := range p.Permissions {
+			if !known[permission] {
+				problems = append(problems, fmt.Sprintf("policy %s: unknown permission %q", name, permission))
+			}
+		}
+
+		for _, source := range pf.When {
+			c, err := parseCondition(source)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("policy %s: condition %q: %v", name, source, err))
+				continue
+			}
+			p.When = append(p.When, c)
+		}
+
+		for _, match := range placeholder.FindAllStringSubmatch(p.Reason, -1) {
+			if _, ok := attributes[match[1]]; !ok {
+				problems = append(problems, fmt.Sprintf("policy %s: unknown attribute %q in reason", name, match[1]))
+			}
+		}
+
+		engine.policies = append(engine.policies, p)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return engine, nil
+}
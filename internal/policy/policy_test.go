@@ -0,0 +1,302 @@
+package policy
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"api/internal/permissions"
+)
+
+func loadDefault(t *testing.T) *Engine {
+	t.Helper()
+	engine, err := Load("")
+	if err != nil {
+		t.Fatalf("built-in policies do not load: %v", err)
+	}
+	return engine
+}
+
+func member(id string, roles ...string) Actor {
+	return Actor{ID: id, Roles: roles}
+}
+
+func staff(id, platformRole string) Actor {
+	return Actor{ID: id, PlatformRole: platformRole}
+}
+
+func role(names ...string) *permissions.Role {
+	roles := make([]*permissions.Role, 0, len(names))
+	for _, name := range names {
+		roles = append(roles, permissions.GetRoleWithRoleName(name))
+	}
+	return permissions.Combine(roles...)
+}
+
+var (
+	activeClub    = Club{ID: "club-1", Status: "active"}
+	pendingClub   = Club{ID: "club-1", Status: "pending"}
+	archivedClub  = Club{ID: "club-1", Status: "archived"}
+	suspendedClub = Club{ID: "club-1", Status: "suspended"}
+	deletedClub   = Club{ID: "club-1", Status: "deleted"}
+)
+
+type policyTest struct {
+	name    string
+	request Request
+	allowed bool
+	// Policy expected to decide the request, empty when the role decides
+	policy string
+}
+
+func runPolicyTests(t *testing.T, tests []policyTest) {
+	t.Helper()
+	engine := loadDefault(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := engine.Evaluate(tt.request)
+			if got.Allowed != tt.allowed || got.Policy != tt.policy {
+				t.Errorf("Evaluate() = %+v, want allowed %v by %q", got, tt.allowed, tt.policy)
+			}
+			if !got.Allowed && got.Reason == "" {
+				t.Error("refusals must carry a reason")
+			}
+		})
+	}
+}
+
+func TestSuspendedClubPolicy(t *testing.T) {
+	runPolicyTests(t, []policyTest{
+		{"owner reads suspended club", Request{Actor: member("u1", "owner"), Club: suspendedClub, Role: role("owner"), Permission: permissions.ClubReadPermission}, false, "suspended-club"},
+		{"admin updates suspended club", Request{Actor: member("u1", "admin"), Club: suspendedClub, Role: role("admin"), Permission: permissions.ClubUpdatePermission}, false, "suspended-club"},
+		{"moderator reads suspended club", Request{Actor: staff("s1", "moderator"), Club: suspendedClub, Role: permissions.ModeratorRole.Club, Permission: permissions.ClubReadPermission}, true, ""},
+		{"superadmin updates suspended club", Request{Actor: staff("s1", "superadmin"), Club: suspendedClub, Role: permissions.SuperadminRole.Club, Permission: permissions.ClubUpdatePermission}, true, ""},
+		{"owner reads active club", Request{Actor: member("u1", "owner"), Club: activeClub, Role: role("owner"), Permission: permissions.ClubReadPermission}, true, ""},
+	})
+}
+
+func TestDeletedClubPolicy(t *testing.T) {
+	runPolicyTests(t, []policyTest{
+		{"owner reads deleted club", Request{Actor: member("u1", "owner"), Club: deletedClub, Role: role("owner"), Permission: permissions.ClubReadPermission, Route: "/api/club"}, false, "deleted-club"},
+		{"owner creates event in deleted club", Request{Actor: member("u1", "owner"), Club: deletedClub, Role: role("owner"), Permission: permissions.EventWritePermission, Route: "/api/event"}, false, "deleted-club"},
+		{"superadmin updates deleted club", Request{Actor: staff("s1", "superadmin"), Club: deletedClub, Role: permissions.SuperadminRole.Club, Permission: permissions.ClubUpdatePermission, Route: "/api/club"}, false, "deleted-club"},
+		{"owner lists trash of deleted club", Request{Actor: member("u1", "owner"), Club: deletedClub, Role: role("owner"), Permission: permissions.ClubUpdatePermission, Route: "/api/club/trash"}, true, ""},
+		{"owner restores deleted club", Request{Actor: member("u1", "owner"), Club: deletedClub, Role: role("owner"), Permission: permissions.ClubUpdatePermission, Route: "/api/club/restore"}, true, ""},
+		{"member restores deleted club", Request{Actor: member("u1", "member"), Club: deletedClub, Role: role("member"), Permission: permissions.ClubUpdatePermission, Route: "/api/club/restore"}, false, ""},
+		{"owner of deleted club outside a request", Request{Actor: member("u1", "owner"), Club: deletedClub, Role: role("owner"), Permission: permissions.ClubReadPermission}, false, "deleted-club"},
+	})
+}
+
+func TestInactiveClubCannotPublishPolicy(t *testing.T) {
+	runPolicyTests(t, []policyTest{
+		{"pending club creates event", Request{Actor: member("u1", "owner"), Club: pendingClub, Role: role("owner"), Permission: permissions.EventWritePermission}, false, "inactive-club-cannot-publish"},
+		{"archived club posts", Request{Actor: member("u1", "admin"), Club: archivedClub, Role: role("admin"), Permission: permissions.ClubWritePermission}, false, "inactive-club-cannot-publish"},
+		{"pending club drafts social post", Request{Actor: member("u1", "social_admin"), Club: pendingClub, Role: role("social_admin"), Permission: permissions.SocialMediaWritePermission}, false, "inactive-club-cannot-publish"},
+		{"archived club schedules social post", Request{Actor: member("u1", "social_admin"), Club: archivedClub, Role: role("social_admin"), Permission: permissions.SocialMediaUpdatePermission}, false, "inactive-club-cannot-publish"},
+		{"pending club updates its details", Request{Actor: member("u1", "owner"), Club: pendingClub, Role: role("owner"), Permission: permissions.ClubUpdatePermission}, true, ""},
+		{"pending club adds member", Request{Actor: member("u1", "owner"), Club: pendingClub, Role: role("owner"), Permission: permissions.AddClubUser}, true, ""},
+		{"active club creates event", Request{Actor: member("u1", "owner"), Club: activeClub, Role: role("owner"), Permission: permissions.EventWritePermission}, true, ""},
+		{"superadmin posts in pending club", Request{Actor: staff("s1", "superadmin"), Club: pendingClub, Role: permissions.SuperadminRole.Club, Permission: permissions.ClubWritePermission}, true, ""},
+	})
+
+	decision := loadDefault(t).Evaluate(Request{Actor: member("u1", "owner"), Club: archivedClub, Role: role("owner"), Permission: permissions.EventWritePermission})
+	if decision.Reason != "archived clubs cannot publish" {
+		t.Errorf("Reason = %q, want the status of the club filled in", decision.Reason)
+	}
+}
+
+func TestResourceOfClubPolicy(t *testing.T) {
+	own := &Resource{Type: "event", ID: "e1", Club: "club-1", Owner: "u2", Status: "active"}
+	other := &Resource{Type: "event", ID: "e2", Club: "club-2", Owner: "u2", Status: "active"}
+	mine := &Resource{Type: "event", ID: "e3", Club: "club-2", Owner: "u1", Status: "active"}
+
+	runPolicyTests(t, []policyTest{
+		{"admin updates event of own club", Request{Actor: member("u1", "admin"), Club: activeClub, Resource: own, Role: role("admin"), Permission: permissions.EventUpdatePermission}, true, ""},
+		{"admin updates event of other club", Request{Actor: member("u1", "admin"), Club: activeClub, Resource: other, Role: role("admin"), Permission: permissions.EventUpdatePermission}, false, "resource-of-club"},
+		{"admin deletes event of other club", Request{Actor: member("u1", "admin"), Club: activeClub, Resource: other, Role: role("admin"), Permission: permissions.EventDeletePermission}, false, "resource-of-club"},
+		{"creator updates own event through other club", Request{Actor: member("u1", "member"), Club: activeClub, Resource: mine, Role: role("member"), Permission: permissions.EventUpdatePermission}, false, "resource-of-club"},
+		{"superadmin updates event of other club", Request{Actor: staff("s1", "superadmin"), Club: activeClub, Resource: other, Role: permissions.SuperadminRole.Club, Permission: permissions.EventUpdatePermission}, false, "resource-of-club"},
+	})
+
+	decision := loadDefault(t).Evaluate(Request{Actor: member("u1", "admin"), Club: activeClub, Resource: other, Role: role("admin"), Permission: permissions.EventUpdatePermission})
+	if decision.Reason != "this event belongs to another club" {
+		t.Errorf("Reason = %q, want the resource type filled in", decision.Reason)
+	}
+}
+
+func TestEventCreatorPolicy(t *testing.T) {
+	event := &Resource{Type: "event", ID: "e1", Club: "club-1", Owner: "u1", Status: "active"}
+	legacy := &Resource{Type: "event", ID: "e2", Club: "club-1", Status: "active"}
+
+	runPolicyTests(t, []policyTest{
+		{"creator updates own event", Request{Actor: member("u1", "member"), Club: activeClub, Resource: event, Role: role("member"), Permission: permissions.EventUpdatePermission}, true, "event-creator"},
+		{"creator deletes own event", Request{Actor: member("u1", "club_admin"), Club: activeClub, Resource: event, Role: role("club_admin"), Permission: permissions.EventDeletePermission}, true, "event-creator"},
+		{"creator restores own event from trash", Request{Actor: member("u1", "member"), Club: activeClub, Resource: event, Role: role("member"), Permission: permissions.EventDeletePermission, Route: "/api/club/restore"}, true, "event-creator"},
+		{"creator reads audit log through own event", Request{Actor: member("u1", "member"), Club: activeClub, Resource: event, Role: role("member"), Permission: permissions.AuditReadPermission}, false, ""},
+		{"other member updates event", Request{Actor: member("u2", "member"), Club: activeClub, Resource: event, Role: role("member"), Permission: permissions.EventUpdatePermission}, false, ""},
+		{"member updates event without creator", Request{Actor: member("u2", "member"), Club: activeClub, Resource: legacy, Role: role("member"), Permission: permissions.EventUpdatePermission}, false, ""},
+		{"admin updates event of someone else", Request{Actor: member("u2", "admin"), Club: activeClub, Resource: event, Role: role("admin"), Permission: permissions.EventUpdatePermission}, true, ""},
+		{"creator updates own event in suspended club", Request{Actor: member("u1", "member"), Club: suspendedClub, Resource: event, Role: role("member"), Permission: permissions.EventUpdatePermission}, false, "suspended-club"},
+	})
+}
+
+// Every built-in policy needs a Test<Name>Policy above.
+func TestEveryPolicyIsTested(t *testing.T) {
+	tested := map[string]bool{
+		"suspended-club":               true,
+		"deleted-club":                 true,
+		"inactive-club-cannot-publish": true,
+		"resource-of-club":             true,
+		"event-creator":                true,
+	}
+	for _, p := range loadDefault(t).Policies() {
+		if !tested[p.Name] {
+			t.Errorf("policy %s has no test", p.Name)
+		}
+		delete(tested, p.Name)
+	}
+	for name := range tested {
+		t.Errorf("tested policy %s is not declared", name)
+	}
+}
+
+func TestEvaluateWithoutPolicies(t *testing.T) {
+	engine := &Engine{}
+
+	if !engine.Evaluate(Request{Role: role("admin"), Permission: permissions.EventWritePermission}).Allowed {
+		t.Error("roles must grant their permissions")
+	}
+
+	decision := engine.Evaluate(Request{Role: role("mail_admin"), Permission: permissions.EventWritePermission})
+	if decision.Allowed {
+		t.Error("roles must not grant other permissions")
+	}
+	if decision.Reason != "the mail_admin role does not grant event:write" {
+		t.Errorf("Reason = %q", decision.Reason)
+	}
+}
+
+func TestGranted(t *testing.T) {
+	engine := loadDefault(t)
+
+	got := engine.Granted(Request{Actor: member("u1", "social_admin"), Club: pendingClub, Role: role("social_admin")})
+	want := []permissions.Permission{
+		permissions.SocialMediaReadPermission,
+		permissions.SocialMediaDeletePermission,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Granted() = %v, want %v", got, want)
+	}
+
+	if got := engine.Granted(Request{Actor: member("u1", "owner"), Club: suspendedClub, Role: role("owner")}); len(got) != 0 {
+		t.Errorf("Granted() in a suspended club = %v, want none", got)
+	}
+
+	// Resource policies do not apply to the club itself
+	got = engine.Granted(Request{
+		Actor:    member("u1", "member"),
+		Club:     activeClub,
+		Resource: &Resource{Type: "event", Club: "club-1", Owner: "u1"},
+		Role:     role("member"),
+	})
+	if len(got) != 0 {
+		t.Errorf("Granted() = %v, want none", got)
+	}
+}
+
+func TestConditions(t *testing.T) {
+	request := &Request{
+		Actor:    Actor{ID: "u1", Roles: []string{"admin", "mail_admin"}},
+		Club:     Club{ID: "club-1", Status: "active"},
+		Resource: &Resource{Type: "event", Club: "club-1", Owner: "u1"},
+	}
+
+	tests := []struct {
+		condition string
+		want      bool
+	}{
+		{"club.status == 'active'", true},
+		{"club.status != 'active'", false},
+		{"resource.owner == actor.id", true},
+		{"resource.club == club.id", true},
+		{"resource.status == []", true},
+		{"resource.status != []", false},
+		{"actor.platform_role == ''", true},
+		{"actor.roles == ['mail_admin', 'admin']", true},
+		{"actor.roles == ['admin']", false},
+		{"'admin' in actor.roles", true},
+		{"'owner' in actor.roles", false},
+		{"actor.roles in ['admin', 'mail_admin', 'owner']", true},
+		{"actor.roles in ['admin']", false},
+		{"resource.status in ['active']", false},
+		{"actor.roles not in ['owner']", true},
+		{"actor.roles not in ['owner', 'admin']", false},
+		{"resource.status not in ['active']", true},
+	}
+	for _, tt := range tests {
+		c, err := parseCondition(tt.condition)
+		if err != nil {
+			t.Errorf("parseCondition(%q) failed: %v", tt.condition, err)
+			continue
+		}
+		if got := c.holds(request); got != tt.want {
+			t.Errorf("%q = %v, want %v", tt.condition, got, tt.want)
+		}
+	}
+}
+
+func TestParseConditionErrors(t *testing.T) {
+	for _, condition := range []string{
+		"",
+		"club.status",
+		"club.status == ",
+		"club.state == 'active'",
+		"club.status = 'active'",
+		"club.status not 'active'",
+		"club.status == 'active",
+		"club.status in ['active' 'pending']",
+		"club.status in ['active',",
+		"club.status == 'active' == 'pending'",
+	} {
+		if _, err := parseCondition(condition); err == nil {
+			t.Errorf("parseCondition(%q) succeeded, want an error", condition)
+		}
+	}
+}
+
+func TestParseValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		problem string
+	}{
+		{"unsupported version", "version: 2\npolicies: []", "version 2 is not supported"},
+		{"missing version", "policies: []", "version 0 is not supported"},
+		{"unnamed policy", "version: 1\npolicies:\n  - effect: deny\n    permissions: ['*']\n    reason: no", "has no name"},
+		{"duplicate name", "version: 1\npolicies:\n  - {name: a, effect: deny, permissions: ['*'], reason: no}\n  - {name: a, effect: deny, permissions: ['*'], reason: no}", "declared twice"},
+		{"unknown effect", "version: 1\npolicies:\n  - {name: a, effect: maybe, permissions: ['*']}", `effect "maybe"`},
+		{"allow without conditions", "version: 1\npolicies:\n  - {name: a, effect: allow, permissions: [event:update]}", "allows without conditions"},
+		{"deny without reason", "version: 1\npolicies:\n  - {name: a, effect: deny, permissions: ['*']}", "denies without a reason"},
+		{"no permissions", "version: 1\npolicies:\n  - {name: a, effect: deny, reason: no}", "covers no permissions"},
+		{"unknown permission", "version: 1\npolicies:\n  - {name: a, effect: deny, permissions: [event:publish], reason: no}", `unknown permission "event:publish"`},
+		{"bad condition", "version: 1\npolicies:\n  - {name: a, effect: deny, permissions: ['*'], reason: no, when: [club.state == 'x']}", `unknown attribute "club.state"`},
+		{"unknown reason attribute", "version: 1\npolicies:\n  - {name: a, effect: deny, permissions: ['*'], reason: '{club.name}'}", `unknown attribute "club.name" in reason`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.file))
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("Parse() error = %v, want a ValidationError", err)
+			}
+			if !strings.Contains(err.Error(), tt.problem) {
+				t.Errorf("Parse() error = %v, want it to mention %q", err, tt.problem)
+			}
+		})
+	}
+
+	if _, err := Parse([]byte("version: 1\npolicies:\n  - {name: a, effect: deny, permisions: ['*']}")); err == nil {
+		t.Error("Parse() accepted an unknown field")
+	}
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const sweepInterval = time.Minute
+
+// MemoryStore keeps the buckets in memory. Limits are only enforced per
+// instance, use PostgresStore when running several instances.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	limit   Limit
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) > sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = refill(limit, b.tokens, now.Sub(b.updated))
+	b.updated = now
+	b.limit = limit
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return newResult(limit, b.tokens, allowed), nil
+}
+
+// sweep drops the buckets that are full again, they behave the same as
+// missing ones.
+func (m *MemoryStore) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if refill(b.limit, b.tokens, now.Sub(b.updated)) >= b.limit.burst() {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+)
+
+const (
+	cleanupInterval = 10 * time.Minute
+	// Buckets idle for longer than this are full for every configured limit
+	// and can be dropped.
+	bucketIdleTime = 24 * time.Hour
+)
+
+// PostgresStore keeps the buckets in the rate_limit_buckets table so all
+// instances share them.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{
+		db: db,
+	}
+}
+
+func (p *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	rateLimitRepository := repository.NewRateLimitRepository(db.WithContext(ctx, p.db))
+	tokens, allowed, err := rateLimitRepository.TakeToken(key, limit.burst(), limit.rate())
+	if err != nil {
+		return Result{}, err
+	}
+	return newResult(limit, tokens, allowed), nil
+}
+
+// Run deletes idle buckets until ctx is cancelled.
+func (p *PostgresStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		rateLimitRepository := repository.NewRateLimitRepository(db.WithContext(ctx, p.db))
+		if err := rateLimitRepository.DeleteIdleBuckets(bucketIdleTime); err != nil {
+			logging.FromContext(ctx).Error("failed to delete idle rate limit buckets", "error", err)
+		}
+	}
+}
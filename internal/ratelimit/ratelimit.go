@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Limit allows Requests per Per on average with bursts of up to Burst
+// requests. A zero Burst defaults to Requests.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.Requests)
+}
+
+// rate is the number of tokens added to the bucket per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset is the time until the bucket is full again.
+	Reset time.Duration
+	// RetryAfter is the time until the next request is allowed. It is zero
+	// when the request was allowed.
+	RetryAfter time.Duration
+}
+
+// Store keeps one token bucket per key.
+type Store interface {
+	// Take removes a token from the bucket of key if there is one.
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill returns the tokens in a bucket that held tokens elapsed ago.
+func refill(limit Limit, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(limit.burst(), tokens+elapsed.Seconds()*limit.rate())
+}
+
+// newResult describes a bucket holding tokens after the request was counted.
+func newResult(limit Limit, tokens float64, allowed bool) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     int(limit.burst()),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((limit.burst() - tokens) / limit.rate()),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.rate())
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Ceil(s)) * time.Second
+}
@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	// One token per second, up to 10
+	limit := Limit{Requests: 60, Per: time.Minute, Burst: 10}
+
+	tests := []struct {
+		name    string
+		limit   Limit
+		tokens  float64
+		elapsed time.Duration
+		want    float64
+	}{
+		{"nothing elapsed", limit, 4, 0, 4},
+		{"refills at the rate", limit, 0, 3 * time.Second, 3},
+		{"refills fractions of a token", limit, 0.5, 500 * time.Millisecond, 1},
+		{"stops at the burst", limit, 5, time.Hour, 10},
+		{"full bucket stays full", limit, 10, time.Second, 10},
+		{"clock going backwards", limit, 4, -time.Minute, 4},
+		{"burst defaults to requests", Limit{Requests: 60, Per: time.Minute}, 0, 2 * time.Hour, 60},
+		{"slow limit", Limit{Requests: 1, Per: 8 * time.Second, Burst: 5}, 1, 4 * time.Second, 1.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := refill(tt.limit, tt.tokens, tt.elapsed); got != tt.want {
+				t.Errorf("refill(%v, %v) = %v, want %v", tt.tokens, tt.elapsed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	// One token per second, up to 10
+	limit := Limit{Requests: 60, Per: time.Minute, Burst: 10}
+	// One token every 8 seconds, up to 2
+	slow := Limit{Requests: 1, Per: 8 * time.Second, Burst: 2}
+	// 8 tokens per second, up to 8
+	fast := Limit{Requests: 8, Per: time.Second}
+
+	tests := []struct {
+		name    string
+		limit   Limit
+		tokens  float64
+		allowed bool
+		want    Result
+	}{
+		{"allowed with tokens left", limit, 9, true, Result{Allowed: true, Limit: 10, Remaining: 9, Reset: time.Second}},
+		{"allowed with the last token", limit, 0, true, Result{Allowed: true, Limit: 10, Remaining: 0, Reset: 10 * time.Second}},
+		{"remaining rounds down", limit, 2.5, true, Result{Allowed: true, Limit: 10, Remaining: 2, Reset: 8 * time.Second}},
+		{"denied waits for the next token", limit, 0.25, false, Result{Limit: 10, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second}},
+		{"denied on a slow limit", slow, 0, false, Result{Limit: 2, Remaining: 0, Reset: 16 * time.Second, RetryAfter: 8 * time.Second}},
+		{"denied on a slow limit with part of a token", slow, 0.5, false, Result{Limit: 2, Remaining: 0, Reset: 12 * time.Second, RetryAfter: 4 * time.Second}},
+		{"retry after rounds up to a second", fast, 0.5, false, Result{Limit: 8, Remaining: 0, Reset: time.Second, RetryAfter: time.Second}},
+		{"burst defaults to requests", fast, 7, true, Result{Allowed: true, Limit: 8, Remaining: 7, Reset: time.Second}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newResult(tt.limit, tt.tokens, tt.allowed); got != tt.want {
+				t.Errorf("newResult(%v, %v) = %+v, want %+v", tt.tokens, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package realtime
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"api/internal/bus"
+	"api/pkg/logging"
+
+	"github.com/lib/pq"
+)
+
+const (
+	channelName = "realtime"
+
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more
+	maxPayloadSize = 7900
+
+	subscriptionBufferSize = 64
+)
+
+// Event is a bus message as received from Postgres. Data is kept as raw JSON
+// because it is only forwarded to clients.
+type Event struct {
+	Topic      bus.Topic       `json:"topic"`
+	ClubID     string          `json:"club_id,omitempty"`
+	ActorID    string          `json:"actor_id,omitempty"`
+	UserID     string          `json:"user_id,omitempty"`
+	Data       json.RawMessage `json:"data,omitempty"`
+	OccurredAt time.Time       `json:"occurred_at"`
+}
+
+// Hub forwards bus messages to the connected clients of every API instance.
+// Messages are published with pg_notify and every instance, including the
+// publishing one, fans them out to its local subscriptions.
+type Hub struct {
+	db            *sql.DB
+	mu            sync.RWMutex
+	subscriptions map[*Subscription]struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func NewHub(db *sql.DB) *Hub {
+	return &Hub{
+		db:            db,
+		subscriptions: make(map[*Subscription]struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// Close signals every subscription to end so open streams don't hold up a
+// server shutdown.
+func (h *Hub) Close() {
+	h.closeOnce.Do(func() {
+		close(h.done)
+	})
+}
+
+// Publish is a bus handler that sends msg to all instances.
+func (h *Hub) Publish(ctx context.Context, msg bus.Message) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		logging.FromContext(ctx).Error("failed to encode realtime message", "topic", msg.Topic, "error", err)
+		return
+	}
+
+	// Clients re-fetch the resource when the data did not fit
+	if len(payload) > maxPayloadSize {
+		msg.Data = nil
+		payload, err = json.Marshal(msg)
+		if err != nil {
+			logging.FromContext(ctx).Error("failed to encode realtime message", "topic", msg.Topic, "error", err)
+			return
+		}
+	}
+
+	if _, err := h.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channelName, string(payload)); err != nil {
+		logging.FromContext(ctx).Error("failed to publish realtime message", "topic", msg.Topic, "error", err)
+	}
+}
+
+// Listen receives the messages of all instances until ctx is cancelled.
+func (h *Hub) Listen(ctx context.Context, connStr string) error {
+	listener := pq.NewListener(connStr, 10*time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logging.FromContext(ctx).Warn("realtime listener", "event", event, "error", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channelName); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification signals a reconnect
+			if notification == nil {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				logging.FromContext(ctx).Error("failed to decode realtime message", "error", err)
+				continue
+			}
+			h.broadcast(event)
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
+
+// Subscribe registers a client of userID that is a member of clubIDs.
+func (h *Hub) Subscribe(userID string, clubIDs []string) *Subscription {
+	subscription := &Subscription{
+		hub:    h,
+		userID: userID,
+		clubs:  make(map[string]bool, len(clubIDs)),
+		events: make(chan Event, subscriptionBufferSize),
+	}
+	for _, clubID := range clubIDs {
+		subscription.clubs[clubID] = true
+	}
+
+	h.mu.Lock()
+	h.subscriptions[subscription] = struct{}{}
+	h.mu.Unlock()
+
+	return subscription
+}
+
+func (h *Hub) broadcast(event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscription := range h.subscriptions {
+		subscription.deliver(event)
+	}
+}
+
+type Subscription struct {
+	hub    *Hub
+	userID string
+
+	mu     sync.Mutex
+	clubs  map[string]bool
+	events chan Event
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the hub shuts down.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.hub.done
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	delete(s.hub.subscriptions, s)
+	s.hub.mu.Unlock()
+}
+
+// Delivers events about the user itself and events of the clubs the user is
+// a member of. Membership changes of the user update the club scope.
+func (s *Subscription) deliver(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	aboutUser := event.UserID != "" && event.UserID == s.userID
+	if aboutUser && event.Topic == bus.ClubMemberAdded {
+		s.clubs[event.ClubID] = true
+	}
+
+	switch {
+	case aboutUser:
+	case event.Topic == bus.NotificationCreated:
+		// Notifications are private to their recipient
+		return
+	case !s.clubs[event.ClubID]:
+		return
+	}
+
+	if aboutUser && event.Topic == bus.ClubMemberRemoved {
+		delete(s.clubs, event.ClubID)
+	}
+
+	// Slow clients miss events instead of blocking the other subscriptions
+	select {
+	case s.events <- event:
+	default:
+	}
+}
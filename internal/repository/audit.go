@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"time"
+)
+
+type AuditRepository struct {
+	db db.Conn
+}
+
+func NewAuditRepository(db db.Conn) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+func (a *AuditRepository) CreateAuditEntry(entry models.AuditEntry) error {
+	_, err := a.db.Exec(`
+		INSERT INTO audit_log (club_id, actor_user_id, action, target_type, target_id, before, after, changes, ip, request_id, created_at)
+		VALUES (NULLIF($1, '')::uuid, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		entry.ClubID,
+		entry.ActorUserID,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		nullJSON(entry.Before),
+		nullJSON(entry.After),
+		nullJSON(entry.Changes),
+		entry.IP,
+		entry.RequestID,
+		time.Now(),
+	)
+	return err
+}
+
+// auditFilterWhere selects the entries of the club $1 matching the filter
+// given by auditFilterArgs.
+const auditFilterWhere = `
+	WHERE club_id = $1
+		AND ($2 = '' OR action = $2)
+		AND ($3 = '' OR actor_user_id = $3)
+		AND ($4 = '' OR target_type = $4)
+		AND ($5 = '' OR target_id = $5)
+		AND ($6::timestamptz IS NULL OR created_at >= $6)
+		AND ($7::timestamptz IS NULL OR created_at < $7)
+		AND ($8 = '' OR (created_at, id) < (NULLIF($8, '')::timestamptz, NULLIF($9, '')::uuid))`
+
+func auditFilterArgs(clubID string, filter models.AuditFilter) []any {
+	return []any{
+		clubID,
+		filter.Action,
+		filter.ActorUserID,
+		filter.TargetType,
+		filter.TargetID,
+		filter.Since,
+		filter.Until,
+		filter.AfterCreatedAt,
+		filter.AfterID,
+	}
+}
+
+// Returns the audit entries of a club matching filter, newest first.
+func (a *AuditRepository) ListAuditEntries(clubID string, filter models.AuditFilter) ([]models.AuditEntry, error) {
+	args := append(auditFilterArgs(clubID, filter), filter.Limit, filter.Offset)
+	rows, err := a.db.Query(`
+		SELECT id, COALESCE(club_id::text, ''), COALESCE(actor_user_id, ''), action, target_type, COALESCE(target_id, ''),
+			before, after, changes, COALESCE(ip, ''), COALESCE(request_id, ''), created_at
+		FROM audit_log`+auditFilterWhere+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $10 OFFSET $11`,
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after, changes []byte
+		err := rows.Scan(
+			&entry.ID,
+			&entry.ClubID,
+			&entry.ActorUserID,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&before,
+			&after,
+			&changes,
+			&entry.IP,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Before = before
+		entry.After = after
+		entry.Changes = changes
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// Counts the audit entries of a club matching filter, up to max.
+func (a *AuditRepository) CountAuditEntries(clubID string, filter models.AuditFilter, max int) (int, error) {
+	var count int
+	err := a.db.QueryRow(`
+		SELECT count(*) FROM (
+			SELECT 1 FROM audit_log`+auditFilterWhere+`
+			LIMIT $10
+		) matching`,
+		append(auditFilterArgs(clubID, filter), max)...,
+	).Scan(&count)
+	return count, err
+}
+
+// Empty JSON is stored as NULL rather than as invalid jsonb.
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return data
+}
@@ -2,38 +2,121 @@ package repository
 
 import (
 	"api/internal/models"
+	"api/internal/permissions"
+	db "api/pkg/database"
 	"database/sql"
+	"errors"
+	"fmt"
 	"time"
+
+	"github.com/lib/pq"
 )
 
+// ErrLastOwner is returned for changes that would leave a club without an
+// owner.
+var ErrLastOwner = errors.New("a club must keep at least one owner")
+
+// activeGrant matches the club_roles rows of cr that are valid at the time
+// passed as the given placeholder.
+func activeGrant(at string) string {
+	return fmt.Sprintf("(cr.valid_from IS NULL OR cr.valid_from <= %[1]s) AND (cr.valid_until IS NULL OR cr.valid_until > %[1]s)", at)
+}
+
 type ClubUserRepository struct {
-	db *sql.DB
+	db db.Conn
 }
 
-func NewClubUserRepository(db *sql.DB) *ClubUserRepository {
+func NewClubUserRepository(db db.Conn) *ClubUserRepository {
 	return &ClubUserRepository{
 		db: db,
 	}
 }
 
-func (c *ClubUserRepository) GetUserRole(clubID, userID string) (string, error) {
-	var role string
-	err := c.db.QueryRow("SELECT role FROM club_roles WHERE club_id = $1 AND user_id = $2", clubID, userID).Scan(&role)
+// GetUserRoles returns the roles the user holds in the club. It returns
+// sql.ErrNoRows when the user is not a member.
+func (c *ClubUserRepository) GetUserRoles(clubID, userID string) ([]string, error) {
+	var roles []string
+	err := c.db.QueryRow(`
+		SELECT array_agg(role ORDER BY role) FROM club_roles
+		WHERE club_id = $1 AND user_id = $2
+		HAVING count(*) > 0`, clubID, userID).Scan(pq.Array(&roles))
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// GetActiveUserRoles is GetUserRoles for grants that are valid right now.
+// Roles that expired or are not valid yet are left out, so are the roles
+// held in a club in the trash.
+func (c *ClubUserRepository) GetActiveUserRoles(clubID, userID string) ([]string, error) {
+	return c.getActiveUserRoles(clubID, userID, "c.deleted_at IS NULL")
+}
+
+// GetTrashedClubRoles is GetActiveUserRoles for a club in the trash, which
+// its members can only list the trash of and restore.
+func (c *ClubUserRepository) GetTrashedClubRoles(clubID, userID string) ([]string, error) {
+	return c.getActiveUserRoles(clubID, userID, "c.deleted_at IS NOT NULL")
+}
+
+func (c *ClubUserRepository) getActiveUserRoles(clubID, userID, clubFilter string) ([]string, error) {
+	var roles []string
+	err := c.db.QueryRow(`
+		SELECT array_agg(cr.role ORDER BY cr.role)
+		FROM club_roles cr
+		JOIN clubs c ON c.id = cr.club_id
+		WHERE cr.club_id = $1 AND cr.user_id = $2 AND `+clubFilter+` AND `+activeGrant("$3")+`
+		HAVING count(*) > 0`, clubID, userID, time.Now()).Scan(pq.Array(&roles))
+	if err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// ListActiveUserClubRoles returns the clubs the user holds a valid grant in
+// with their status and the roles held, ordered by club. Permissions are
+// left for the caller to fill in.
+func (c *ClubUserRepository) ListActiveUserClubRoles(userID string) ([]models.ClubPermissions, error) {
+	clubs := []models.ClubPermissions{}
+
+	rows, err := c.db.Query(`
+		SELECT c.id, c.status, array_agg(cr.role ORDER BY cr.role)
+		FROM clubs c
+		JOIN club_roles cr ON c.id = cr.club_id
+		WHERE cr.user_id = $1 AND c.deleted_at IS NULL AND `+activeGrant("$2")+`
+		GROUP BY c.id
+		ORDER BY c.id`, userID, time.Now())
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var club models.ClubPermissions
+		if err := rows.Scan(&club.ClubID, &club.Status, pq.Array(&club.Roles)); err != nil {
+			return nil, err
+		}
+		clubs = append(clubs, club)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return role, nil
+	return clubs, nil
 }
 
 func (c *ClubUserRepository) GetClubsWithUserID(userID string) ([]models.ClubWithRole, error) {
 	var clubs []models.ClubWithRole
 
 	rows, err := c.db.Query(`
-		SELECT c.id, c.name, c.description, c.email, cr.role
+		SELECT c.id, c.name, c.description, c.email, array_agg(cr.role ORDER BY cr.role)
 		FROM clubs c
 		JOIN club_roles cr ON c.id = cr.club_id
-		WHERE cr.user_id = $1 AND cr.role != 'member';`, userID)
+		WHERE cr.user_id = $1 AND cr.role != 'member' AND c.deleted_at IS NULL AND `+activeGrant("$2")+`
+		GROUP BY c.id`, userID, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -46,7 +129,7 @@ func (c *ClubUserRepository) GetClubsWithUserID(userID string) ([]models.ClubWit
 			&club.Name,
 			&club.Description,
 			&club.Email,
-			&club.Role,
+			pq.Array(&club.Roles),
 		)
 		if err != nil {
 			return nil, err
@@ -61,17 +144,25 @@ func (c *ClubUserRepository) GetClubsWithUserID(userID string) ([]models.ClubWit
 	return clubs, nil
 }
 
-func (c *ClubUserRepository) CreateClubRole(clubID string, userID string, role string) (bool, error) {
-	_, err := c.db.Exec(`
-		INSERT INTO club_roles (user_id, club_id, role, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $4)`,
-		userID, clubID, role, time.Now(),
+// Grants role to the user, adding them as a member when needed. It returns
+// false when the user already holds the role.
+func (c *ClubUserRepository) CreateClubRole(clubID string, userID string, role string, grant models.RoleGrant) (bool, error) {
+	result, err := c.db.Exec(`
+		INSERT INTO club_roles (user_id, club_id, role, valid_from, valid_until, granted_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $7)
+		ON CONFLICT (user_id, club_id, role) DO NOTHING`,
+		userID, clubID, role, grant.ValidFrom, grant.ValidUntil, grant.GrantedBy, time.Now(),
 	)
 	if err != nil {
 		return false, err
 	}
 
-	return true, nil
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return created > 0, nil
 }
 
 func (c *ClubUserRepository) DeleteAllClubRoles(clubID string) error {
@@ -87,7 +178,14 @@ func (c *ClubUserRepository) DeleteAllClubRoles(clubID string) error {
 	return nil
 }
 
-func (c *ClubUserRepository) DeleteClubRole(clubID string, userID string) error {
+// Removes a member and all their roles from the club. Removing the last
+// owner fails with ErrLastOwner. It has to be called in a unit of work, see
+// lockOwners.
+func (c *ClubUserRepository) DeleteClubMember(clubID string, userID string) error {
+	if err := c.ensureOtherOwner(clubID, userID); err != nil {
+		return err
+	}
+
 	_, err := c.db.Exec(`
 		DELETE FROM club_roles 
 		WHERE club_id = $1 AND user_id = $2`,
@@ -100,17 +198,111 @@ func (c *ClubUserRepository) DeleteClubRole(clubID string, userID string) error
 	return nil
 }
 
-func (c *ClubUserRepository) UpdateClubRole(clubID string, userID string, role string) error {
+// Revokes role from a member, who leaves the club once their last role is
+// revoked. It returns false when the member does not hold the role.
+// Revoking ownership from the last owner fails with ErrLastOwner. It has to
+// be called in a unit of work, see lockOwners.
+func (c *ClubUserRepository) DeleteClubRole(clubID string, userID string, role string) (bool, error) {
+	if role == permissions.OwnerRole.Name {
+		if err := c.ensureOtherOwner(clubID, userID); err != nil {
+			return false, err
+		}
+	}
+
+	result, err := c.db.Exec(`
+		DELETE FROM club_roles 
+		WHERE club_id = $1 AND user_id = $2 AND role = $3`,
+		clubID, userID, role,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return deleted > 0, nil
+}
+
+// Replaces all roles of a member with role, granted for the given period.
+// Demoting the last owner fails with ErrLastOwner. It has to be called in a
+// unit of work, see lockOwners.
+func (c *ClubUserRepository) SetClubRole(clubID string, userID string, role string, grant models.RoleGrant) error {
+	if role != permissions.OwnerRole.Name {
+		if err := c.ensureOtherOwner(clubID, userID); err != nil {
+			return err
+		}
+	}
+
 	_, err := c.db.Exec(`
-		UPDATE club_roles 
-		SET role = $3, updated_at = $4
-		WHERE club_id = $1 AND user_id = $2`,
-		clubID, userID, role, time.Now(),
+		DELETE FROM club_roles 
+		WHERE club_id = $1 AND user_id = $2 AND role <> $3`,
+		clubID, userID, role,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.db.Exec(`
+		INSERT INTO club_roles (user_id, club_id, role, valid_from, valid_until, granted_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $7)
+		ON CONFLICT (user_id, club_id, role) DO UPDATE
+		SET valid_from = EXCLUDED.valid_from, valid_until = EXCLUDED.valid_until, granted_by = EXCLUDED.granted_by,
+			expiry_notified_at = NULL, updated_at = EXCLUDED.updated_at`,
+		userID, clubID, role, grant.ValidFrom, grant.ValidUntil, grant.GrantedBy, time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Makes the user an owner of the club, adding them as a member when needed.
+// Their other roles are kept. Ownership is never time-limited.
+func (c *ClubUserRepository) AddOwner(clubID string, userID string) error {
+	_, err := c.db.Exec(`
+		INSERT INTO club_roles (user_id, club_id, role, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $4)
+		ON CONFLICT (user_id, club_id, role) DO NOTHING`,
+		userID, clubID, permissions.OwnerRole.Name, time.Now(),
 	)
+	return err
+}
+
+// lockOwners serialises changes to the owners of a club by locking its row
+// until the end of the transaction. Statements that run after it in the same
+// transaction see the owners as left by concurrent transactions, which is
+// why callers must run in a unit of work: outside of one the lock is
+// released right away.
+func (c *ClubUserRepository) lockOwners(clubID string) error {
+	_, err := c.db.Exec(`SELECT id FROM clubs WHERE id = $1 FOR NO KEY UPDATE`, clubID)
+	return err
+}
+
+// ensureOtherOwner returns ErrLastOwner when userID is the only owner of the
+// club. Members who are not owners can always be changed.
+func (c *ClubUserRepository) ensureOtherOwner(clubID string, userID string) error {
+	if err := c.lockOwners(clubID); err != nil {
+		return err
+	}
+
+	var isOwner, otherOwners bool
+	err := c.db.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM club_roles WHERE club_id = $1 AND user_id = $2 AND role = $3),
+			EXISTS (SELECT 1 FROM club_roles WHERE club_id = $1 AND user_id <> $2 AND role = $3)`,
+		clubID, userID, permissions.OwnerRole.Name,
+	).Scan(&isOwner, &otherOwners)
 	if err != nil {
 		return err
 	}
 
+	if isOwner && !otherOwners {
+		return ErrLastOwner
+	}
 	return nil
 }
 
@@ -118,10 +310,11 @@ func (c *ClubUserRepository) GetUserClubsWithRoles(userID string) ([]models.User
 	var clubs []models.UserClubWithRole
 
 	rows, err := c.db.Query(`
-		SELECT c.id, c.name, c.description, cr.role
+		SELECT c.id, c.name, c.description, array_agg(cr.role ORDER BY cr.role)
 		FROM clubs c
 		JOIN club_roles cr ON c.id = cr.club_id
-		WHERE cr.user_id = $1;`, userID)
+		WHERE cr.user_id = $1 AND c.deleted_at IS NULL AND `+activeGrant("$2")+`
+		GROUP BY c.id`, userID, time.Now())
 	if err != nil {
 		return nil, err
 	}
@@ -133,7 +326,7 @@ func (c *ClubUserRepository) GetUserClubsWithRoles(userID string) ([]models.User
 			&club.ClubID,
 			&club.ClubName,
 			&club.Description,
-			&club.Role,
+			pq.Array(&club.Roles),
 		)
 		if err != nil {
 			return nil, err
@@ -152,14 +345,16 @@ func (c *ClubUserRepository) GetClubDetailsWithMembers(clubID string) (*models.C
 	// Get club details
 	var club models.Club
 	err := c.db.QueryRow(`
-		SELECT id, name, description, email, member_count, created_at, updated_at
+		SELECT id, name, description, email, member_count, status, verified_at IS NOT NULL, created_at, updated_at
 		FROM clubs
-		WHERE id = $1`, clubID).Scan(
+		WHERE id = $1 AND deleted_at IS NULL`, clubID).Scan(
 		&club.ID,
 		&club.Name,
 		&club.Description,
 		&club.Email,
 		&club.MemberCount,
+		&club.Status,
+		&club.Verified,
 		&club.CreatedAt,
 		&club.UpdatedAt,
 	)
@@ -167,30 +362,46 @@ func (c *ClubUserRepository) GetClubDetailsWithMembers(clubID string) (*models.C
 		return nil, nil, err
 	}
 
-	// Get club members
+	// Get club members, including the ones whose role is not valid yet
 	rows, err := c.db.Query(`
-		SELECT u.id, u.first_name, u.last_name, u.email, cr.role
+		SELECT u.id, u.first_name, u.last_name, u.email, cr.role, COALESCE(cr.granted_by, ''), cr.valid_from, cr.valid_until
 		FROM users u
 		JOIN club_roles cr ON u.id = cr.user_id
-		WHERE cr.club_id = $1`, clubID)
+		WHERE cr.club_id = $1 AND (cr.valid_until IS NULL OR cr.valid_until > $2)
+		ORDER BY u.id, cr.role`, clubID, time.Now())
 	if err != nil {
 		return nil, nil, err
 	}
 	defer rows.Close()
 
+	// One row per role, the rows of a member are next to each other
 	var members []models.ClubMember
 	for rows.Next() {
 		var member models.ClubMember
+		var role models.ClubMemberRole
 		err := rows.Scan(
 			&member.UserID,
 			&member.FirstName,
 			&member.LastName,
 			&member.Email,
-			&member.Role,
+			&role.Role,
+			&role.GrantedBy,
+			&role.ValidFrom,
+			&role.ValidUntil,
 		)
 		if err != nil {
 			return nil, nil, err
 		}
+		if role.ValidUntil != nil {
+			expiresIn := int64(time.Until(*role.ValidUntil) / time.Second)
+			role.ExpiresIn = &expiresIn
+		}
+
+		if last := len(members) - 1; last >= 0 && members[last].UserID == member.UserID {
+			members[last].Roles = append(members[last].Roles, role)
+			continue
+		}
+		member.Roles = []models.ClubMemberRole{role}
 		members = append(members, member)
 	}
 
@@ -200,3 +411,118 @@ func (c *ClubUserRepository) GetClubDetailsWithMembers(clubID string) (*models.C
 
 	return &club, members, nil
 }
+
+// ClaimExpiringGrants marks the time-limited roles that expire before the
+// given time as notified and returns them, so every member is told only once
+// that their role is about to expire.
+func (c *ClubUserRepository) ClaimExpiringGrants(before time.Time, limit int) ([]models.ExpiringGrant, error) {
+	rows, err := c.db.Query(`
+		UPDATE club_roles cr
+		SET expiry_notified_at = $1
+		FROM users u, clubs c
+		WHERE u.id = cr.user_id AND c.id = cr.club_id
+			AND (cr.user_id, cr.club_id, cr.role) IN (
+				SELECT due.user_id, due.club_id, due.role
+				FROM club_roles due
+				WHERE due.expiry_notified_at IS NULL AND due.valid_until > $1 AND due.valid_until <= $2
+				ORDER BY due.valid_until
+				LIMIT $3
+				FOR UPDATE OF due SKIP LOCKED
+			)
+			AND c.deleted_at IS NULL
+		RETURNING cr.user_id, u.email, COALESCE(u.email_preferences, 'true'), cr.club_id, c.name, cr.role, cr.valid_until, true`,
+		time.Now(), before, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanExpiringGrants(rows)
+}
+
+// DeleteExpiredGrants revokes the roles that have expired. Owners are never
+// time-limited, they are skipped so a club cannot lose its last owner this
+// way.
+func (c *ClubUserRepository) DeleteExpiredGrants() ([]models.ExpiringGrant, error) {
+	// The outer query sees club_roles as it was before the delete, hence the
+	// delete condition is repeated to find the roles that are left
+	rows, err := c.db.Query(`
+		WITH expired AS (
+			DELETE FROM club_roles
+			WHERE valid_until <= $1 AND role <> $2
+			RETURNING user_id, club_id, role, valid_until
+		)
+		SELECT e.user_id, u.email, COALESCE(u.email_preferences, 'true'), e.club_id, c.name, e.role, e.valid_until,
+			EXISTS (
+				SELECT 1 FROM club_roles left_role
+				WHERE left_role.user_id = e.user_id AND left_role.club_id = e.club_id
+					AND NOT (COALESCE(left_role.valid_until <= $1, false) AND left_role.role <> $2)
+			)
+		FROM expired e
+		JOIN users u ON u.id = e.user_id
+		JOIN clubs c ON c.id = e.club_id`,
+		time.Now(), permissions.OwnerRole.Name,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanExpiringGrants(rows)
+}
+
+// Returns the members of a club holding role.
+func (c *ClubUserRepository) ListUserIDsWithRole(clubID string, role string) ([]string, error) {
+	rows, err := c.db.Query(`
+		SELECT user_id FROM club_roles
+		WHERE club_id = $1 AND role = $2`,
+		clubID, role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+func scanExpiringGrants(rows *sql.Rows) ([]models.ExpiringGrant, error) {
+	var grants []models.ExpiringGrant
+	for rows.Next() {
+		var grant models.ExpiringGrant
+		err := rows.Scan(
+			&grant.UserID,
+			&grant.Email,
+			&grant.EmailPreferences,
+			&grant.ClubID,
+			&grant.ClubName,
+			&grant.Role,
+			&grant.ValidUntil,
+			&grant.StillMember,
+		)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
@@ -2,15 +2,16 @@ package repository
 
 import (
 	"api/internal/models"
+	db "api/pkg/database"
 	"database/sql"
 	"time"
 )
 
 type ClubRepository struct {
-	db *sql.DB
+	db db.Conn
 }
 
-func NewClubRepository(db *sql.DB) *ClubRepository {
+func NewClubRepository(db db.Conn) *ClubRepository {
 	return &ClubRepository{
 		db: db,
 	}
@@ -19,13 +20,14 @@ func NewClubRepository(db *sql.DB) *ClubRepository {
 func (r *ClubRepository) CreateClub(club models.Club) (string, error) {
 	var clubID string
 	err := r.db.QueryRow(`
-		INSERT INTO clubs (name, description, email, member_count, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO clubs (name, description, email, member_count, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id`,
 		club.Name,
 		club.Description,
 		club.Email,
 		club.MemberCount,
+		models.ClubStatusPending,
 		time.Now(),
 		time.Now(),
 	).Scan(&clubID)
@@ -40,9 +42,9 @@ func (r *ClubRepository) CreateClub(club models.Club) (string, error) {
 func (r *ClubRepository) GetClubByID(clubID string) (*models.Club, error) {
 	var club models.Club
 	err := r.db.QueryRow(`
-		SELECT id, name, description, email, member_count, created_at, updated_at
+		SELECT id, name, description, email, member_count, status, verified_at IS NOT NULL, created_at, updated_at
 		FROM clubs
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 		clubID,
 	).Scan(
 		&club.ID,
@@ -50,6 +52,8 @@ func (r *ClubRepository) GetClubByID(clubID string) (*models.Club, error) {
 		&club.Description,
 		&club.Email,
 		&club.MemberCount,
+		&club.Status,
+		&club.Verified,
 		&club.CreatedAt,
 		&club.UpdatedAt,
 	)
@@ -72,7 +76,7 @@ func (r *ClubRepository) UpdateClub(club models.Club) error {
 			email = $3,
 			member_count = $4,
 			updated_at = $5
-		WHERE id = $6`,
+		WHERE id = $6 AND deleted_at IS NULL`,
 		club.Name,
 		club.Description,
 		club.Email,
@@ -84,17 +88,109 @@ func (r *ClubRepository) UpdateClub(club models.Club) error {
 	return err
 }
 
+// DeleteClub moves a club to the trash. It disappears from every listing
+// until it is restored or purged.
 func (r *ClubRepository) DeleteClub(clubID string) error {
-	_, err := r.db.Exec("DELETE FROM clubs WHERE id = $1", clubID)
+	_, err := r.db.Exec(`
+		UPDATE clubs
+		SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		clubID, time.Now(),
+	)
 	return err
 }
 
+// RestoreClub takes a club out of the trash. It returns false when the club
+// is not in the trash.
+func (r *ClubRepository) RestoreClub(clubID string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE clubs
+		SET deleted_at = NULL, updated_at = $2
+		WHERE id = $1 AND deleted_at IS NOT NULL`,
+		clubID, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Returns the clubs that were moved to the trash before the given time.
+func (r *ClubRepository) ListDeletedClubIDs(before time.Time) ([]string, error) {
+	rows, err := r.db.Query(`
+		SELECT id
+		FROM clubs
+		WHERE deleted_at < $1`,
+		before,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clubIDs []string
+	for rows.Next() {
+		var clubID string
+		if err := rows.Scan(&clubID); err != nil {
+			return nil, err
+		}
+		clubIDs = append(clubIDs, clubID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clubIDs, nil
+}
+
+// clubCascade deletes a club and every row that references it, children
+// before their parents. Event reminders, webhook deliveries and social posts
+// of an account go with their parent through ON DELETE CASCADE, and social
+// posts of other clubs linking one of the events keep the post with a NULL
+// event. The audit log is kept on purpose.
+var clubCascade = []string{
+	`DELETE FROM likes WHERE post_id IN (SELECT id FROM feed_posts WHERE author_club_id = $1)`,
+	`DELETE FROM comments WHERE post_id IN (SELECT id FROM feed_posts WHERE author_club_id = $1)`,
+	`DELETE FROM feed_posts WHERE author_club_id = $1`,
+	`DELETE FROM gallery_posts WHERE author_club_id = $1`,
+	`DELETE FROM mails WHERE author_club_id = $1`,
+	`DELETE FROM social_posts WHERE club_id = $1`,
+	`DELETE FROM social_accounts WHERE club_id = $1`,
+	`DELETE FROM webhooks WHERE club_id = $1`,
+	`DELETE FROM notifications WHERE club_id = $1`,
+	`DELETE FROM attended_events WHERE event_id IN (SELECT id FROM events WHERE club_id = $1)`,
+	`DELETE FROM events WHERE club_id = $1`,
+	`DELETE FROM club_roles WHERE club_id = $1`,
+	`DELETE FROM clubs WHERE id = $1`,
+}
+
+// PurgeClub permanently deletes a club together with its members, events,
+// posts, mails and integrations. It runs several statements, so it has to be
+// called in a unit of work to be atomic.
+func (r *ClubRepository) PurgeClub(clubID string) error {
+	for _, stmt := range clubCascade {
+		if _, err := r.db.Exec(stmt, clubID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListClubs returns the active clubs. Clubs waiting for approval, rejected,
+// suspended or archived are left out.
 func (r *ClubRepository) ListClubs() ([]models.Club, error) {
 	rows, err := r.db.Query(`
-		SELECT id, name, description, email, member_count, created_at, updated_at
+		SELECT id, name, description, email, member_count, status, verified_at IS NOT NULL, created_at, updated_at
 		FROM clubs
+		WHERE deleted_at IS NULL AND status = $1
 		ORDER BY created_at DESC
-	`)
+	`, models.ClubStatusActive)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +205,8 @@ func (r *ClubRepository) ListClubs() ([]models.Club, error) {
 			&club.Description,
 			&club.Email,
 			&club.MemberCount,
+			&club.Status,
+			&club.Verified,
 			&club.CreatedAt,
 			&club.UpdatedAt,
 		)
@@ -124,3 +222,63 @@ func (r *ClubRepository) ListClubs() ([]models.Club, error) {
 
 	return clubs, nil
 }
+
+// GetClubStatus returns the status of a club, deleted when it is in the
+// trash and empty when the club does not exist.
+func (r *ClubRepository) GetClubStatus(clubID string) (string, error) {
+	var status string
+	err := r.db.QueryRow(`
+		SELECT CASE WHEN deleted_at IS NULL THEN status ELSE $2 END
+		FROM clubs WHERE id = $1`, clubID, models.ClubStatusDeleted).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// SetClubStatus moves a club from one status to another. It returns false
+// when the club does not exist or is not in status from.
+func (r *ClubRepository) SetClubStatus(clubID, from, to, reason, changedBy string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE clubs
+		SET status = $3, status_reason = NULLIF($4, ''), status_changed_by = NULLIF($5, ''),
+			status_changed_at = $6, updated_at = $6
+		WHERE id = $1 AND status = $2 AND deleted_at IS NULL`,
+		clubID, from, to, reason, changedBy, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// SetClubVerified gives a club the verified badge or takes it away. Clubs
+// that were never approved cannot be verified. It returns false when there
+// was nothing to change.
+func (r *ClubRepository) SetClubVerified(clubID string, verified bool) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE clubs
+		SET verified_at = CASE WHEN $2 THEN $3::timestamp END, updated_at = $3
+		WHERE id = $1 AND deleted_at IS NULL AND status NOT IN ($4, $5)
+			AND (verified_at IS NOT NULL) <> $2`,
+		clubID, verified, time.Now(), models.ClubStatusPending, models.ClubStatusRejected,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
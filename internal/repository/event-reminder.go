@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type EventReminderRepository struct {
+	db db.Conn
+}
+
+func NewEventReminderRepository(db db.Conn) *EventReminderRepository {
+	return &EventReminderRepository{
+		db: db,
+	}
+}
+
+// Replaces the pending reminders of an event with one reminder per offset
+// that still lies in the future. Reminders that were already sent are kept.
+func (e *EventReminderRepository) ScheduleReminders(eventID string, offsetMinutes []int) error {
+	now := time.Now()
+	_, err := e.db.Exec(`
+		WITH removed AS (
+			DELETE FROM event_reminders
+			WHERE event_id = $1 AND sent_at IS NULL
+		)
+		INSERT INTO event_reminders (event_id, offset_minutes, remind_at, created_at, updated_at)
+		SELECT e.id, o.minutes, e.start_date - make_interval(mins => o.minutes), $3, $3
+		FROM events e
+		CROSS JOIN unnest($2::integer[]) AS o(minutes)
+		WHERE e.id = $1
+			AND e.status = $4
+			AND e.deleted_at IS NULL
+			AND e.start_date - make_interval(mins => o.minutes) > $3`,
+		eventID, pq.Array(offsetMinutes), now, models.EventStatusActive,
+	)
+	return err
+}
+
+func (e *EventReminderRepository) DeletePendingReminders(eventID string) error {
+	_, err := e.db.Exec(`
+		DELETE FROM event_reminders
+		WHERE event_id = $1 AND sent_at IS NULL`,
+		eventID,
+	)
+	return err
+}
+
+// Claims due reminders for the length of lease and returns them. Claimed
+// reminders are skipped by other instances until they are marked as sent or
+// the lease expires, e.g. because the instance sending them crashed, after
+// which they are claimed again.
+func (e *EventReminderRepository) ClaimDueReminders(limit int, lease time.Duration) ([]models.EventReminder, error) {
+	now := time.Now()
+	rows, err := e.db.Query(`
+		UPDATE event_reminders r
+		SET claimed_until = $4, updated_at = $1
+		FROM events e
+		WHERE r.event_id = e.id
+			AND r.id IN (
+				SELECT due.id
+				FROM event_reminders due
+				JOIN events ev ON ev.id = due.event_id
+				JOIN clubs c ON c.id = ev.club_id
+				WHERE due.sent_at IS NULL AND due.remind_at <= $1 AND ev.status = $3 AND ev.deleted_at IS NULL
+					AND (due.claimed_until IS NULL OR due.claimed_until <= $1)
+					AND c.deleted_at IS NULL
+				ORDER BY due.remind_at
+				LIMIT $2
+				FOR UPDATE OF due SKIP LOCKED
+			)
+		RETURNING r.id, e.id, e.club_id, e.title, e.location, e.start_date, r.offset_minutes`,
+		now, limit, models.EventStatusActive, now.Add(lease),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reminders []models.EventReminder
+	for rows.Next() {
+		var reminder models.EventReminder
+		err := rows.Scan(
+			&reminder.ID,
+			&reminder.EventID,
+			&reminder.ClubID,
+			&reminder.Title,
+			&reminder.Location,
+			&reminder.StartDate,
+			&reminder.OffsetMinutes,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Marks a claimed reminder as sent once it was dispatched to the attendees.
+func (e *EventReminderRepository) MarkReminderSent(reminderID string) error {
+	now := time.Now()
+	_, err := e.db.Exec(`
+		UPDATE event_reminders
+		SET sent_at = $1, claimed_until = NULL, updated_at = $1
+		WHERE id = $2`,
+		now, reminderID,
+	)
+	return err
+}
@@ -2,15 +2,16 @@ package repository
 
 import (
 	"api/internal/models"
+	db "api/pkg/database"
 	"database/sql"
 	"time"
 )
 
 type EventRepository struct {
-	db *sql.DB
+	db db.Conn
 }
 
-func NewEventRepository(db *sql.DB) *EventRepository {
+func NewEventRepository(db db.Conn) *EventRepository {
 	return &EventRepository{
 		db: db,
 	}
@@ -19,10 +20,10 @@ func NewEventRepository(db *sql.DB) *EventRepository {
 func (e *EventRepository) CreateEvent(event *models.Event) (*models.Event, error) {
 	var newEvent models.Event
 	err := e.db.QueryRow(`
-		INSERT INTO events (club_id, title, description, start_date, end_date, tags, location, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
-		RETURNING id, club_id, title, description, start_date, end_date,tags, location, created_at, updated_at`,
-		event.ClubID, event.Title, event.Description, event.StartDate, event.EndDate, event.Tags, event.Location, time.Now(),
+		INSERT INTO events (club_id, title, description, start_date, end_date, tags, location, created_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9, $9)
+		RETURNING id, club_id, title, description, start_date, end_date,tags, location, status, COALESCE(created_by, ''), created_at, updated_at`,
+		event.ClubID, event.Title, event.Description, event.StartDate, event.EndDate, event.Tags, event.Location, event.CreatedBy, time.Now(),
 	).Scan(
 		&newEvent.ID,
 		&newEvent.ClubID,
@@ -32,6 +33,8 @@ func (e *EventRepository) CreateEvent(event *models.Event) (*models.Event, error
 		&newEvent.EndDate,
 		&newEvent.Tags,
 		&newEvent.Location,
+		&newEvent.Status,
+		&newEvent.CreatedBy,
 		&newEvent.CreatedAt,
 		&newEvent.UpdatedAt,
 	)
@@ -44,9 +47,9 @@ func (e *EventRepository) CreateEvent(event *models.Event) (*models.Event, error
 func (e *EventRepository) GetEventByID(eventID string) (*models.Event, error) {
 	var event models.Event
 	err := e.db.QueryRow(`
-		SELECT id, club_id, title, description, start_date, end_date, tags, location, created_at, updated_at
+		SELECT id, club_id, title, description, start_date, end_date, tags, location, status, COALESCE(created_by, ''), created_at, updated_at
 		FROM events
-		WHERE id = $1`, eventID,
+		WHERE id = $1 AND deleted_at IS NULL`, eventID,
 	).Scan(
 		&event.ID,
 		&event.ClubID,
@@ -56,6 +59,8 @@ func (e *EventRepository) GetEventByID(eventID string) (*models.Event, error) {
 		&event.EndDate,
 		&event.Tags,
 		&event.Location,
+		&event.Status,
+		&event.CreatedBy,
 		&event.CreatedAt,
 		&event.UpdatedAt,
 	)
@@ -67,9 +72,11 @@ func (e *EventRepository) GetEventByID(eventID string) (*models.Event, error) {
 
 func (e *EventRepository) GetAllEvents() ([]models.Event, error) {
 	rows, err := e.db.Query(`
-		SELECT id, club_id, title, description, start_date, end_date, tags, location, created_at, updated_at
-		FROM events
-		ORDER BY start_date DESC`)
+		SELECT e.id, e.club_id, e.title, e.description, e.start_date, e.end_date, e.tags, e.location, e.status, COALESCE(e.created_by, ''), e.created_at, e.updated_at
+		FROM events e
+		JOIN clubs c ON c.id = e.club_id
+		WHERE e.deleted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY e.start_date DESC`)
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +94,8 @@ func (e *EventRepository) GetAllEvents() ([]models.Event, error) {
 			&event.EndDate,
 			&event.Tags,
 			&event.Location,
+			&event.Status,
+			&event.CreatedBy,
 			&event.CreatedAt,
 			&event.UpdatedAt,
 		)
@@ -108,8 +117,8 @@ func (e *EventRepository) UpdateEvent(eventID string, event *models.UpdateEventP
 	err := e.db.QueryRow(`
 		UPDATE events 
 		SET title = $1, description = $2, start_date = $3, end_date = $4, location = $5, updated_at = $6
-		WHERE id = $7
-		RETURNING id, club_id, title, description, start_date, end_date, location, created_at, updated_at`,
+		WHERE id = $7 AND deleted_at IS NULL
+		RETURNING id, club_id, title, description, start_date, end_date, location, status, COALESCE(created_by, ''), created_at, updated_at`,
 		event.Title, event.Description, event.StartDate, event.EndDate, event.Location, time.Now(), eventID,
 	).Scan(
 		&updatedEvent.ID,
@@ -119,6 +128,8 @@ func (e *EventRepository) UpdateEvent(eventID string, event *models.UpdateEventP
 		&updatedEvent.StartDate,
 		&updatedEvent.EndDate,
 		&updatedEvent.Location,
+		&updatedEvent.Status,
+		&updatedEvent.CreatedBy,
 		&updatedEvent.CreatedAt,
 		&updatedEvent.UpdatedAt,
 	)
@@ -128,10 +139,184 @@ func (e *EventRepository) UpdateEvent(eventID string, event *models.UpdateEventP
 	return &updatedEvent, nil
 }
 
+// Moves an event to the trash of its club.
 func (e *EventRepository) DeleteEvent(eventID string) error {
-	_, err := e.db.Exec(`DELETE FROM events WHERE id = $1`, eventID)
+	_, err := e.db.Exec(`
+		UPDATE events
+		SET deleted_at = $2
+		WHERE id = $1 AND deleted_at IS NULL`,
+		eventID, time.Now(),
+	)
+	return err
+}
+
+// Returns an event of the club that is in the trash, nil when the club has
+// no such event in the trash.
+func (e *EventRepository) GetTrashedEvent(clubID, eventID string) (*models.Event, error) {
+	var event models.Event
+	err := e.db.QueryRow(`
+		SELECT id, club_id, title, description, start_date, end_date, tags, location, status, COALESCE(created_by, ''), created_at, updated_at
+		FROM events
+		WHERE id = $1 AND club_id = $2 AND deleted_at IS NOT NULL`,
+		eventID, clubID,
+	).Scan(
+		&event.ID,
+		&event.ClubID,
+		&event.Title,
+		&event.Description,
+		&event.StartDate,
+		&event.EndDate,
+		&event.Tags,
+		&event.Location,
+		&event.Status,
+		&event.CreatedBy,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Takes an event of the club out of the trash. It returns nil when the club
+// has no such event in the trash.
+func (e *EventRepository) RestoreEvent(clubID, eventID string) (*models.Event, error) {
+	var event models.Event
+	err := e.db.QueryRow(`
+		UPDATE events
+		SET deleted_at = NULL, updated_at = $3
+		WHERE id = $1 AND club_id = $2 AND deleted_at IS NOT NULL
+		RETURNING id, club_id, title, description, start_date, end_date, tags, location, status, COALESCE(created_by, ''), created_at, updated_at`,
+		eventID, clubID, time.Now(),
+	).Scan(
+		&event.ID,
+		&event.ClubID,
+		&event.Title,
+		&event.Description,
+		&event.StartDate,
+		&event.EndDate,
+		&event.Tags,
+		&event.Location,
+		&event.Status,
+		&event.CreatedBy,
+		&event.CreatedAt,
+		&event.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Permanently deletes the events moved to the trash before the given time,
+// along with their attendance. Reminders go with the events through ON
+// DELETE CASCADE.
+func (e *EventRepository) PurgeDeletedEvents(before time.Time) (int64, error) {
+	_, err := e.db.Exec(`
+		DELETE FROM attended_events
+		WHERE event_id IN (SELECT id FROM events WHERE deleted_at < $1)`,
+		before,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := e.db.Exec(`DELETE FROM events WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (e *EventRepository) CancelEvent(eventID string) (*models.Event, error) {
+	var cancelledEvent models.Event
+	err := e.db.QueryRow(`
+		UPDATE events
+		SET status = $1, updated_at = $2
+		WHERE id = $3 AND deleted_at IS NULL
+		RETURNING id, club_id, title, description, start_date, end_date, tags, location, status, COALESCE(created_by, ''), created_at, updated_at`,
+		models.EventStatusCancelled, time.Now(), eventID,
+	).Scan(
+		&cancelledEvent.ID,
+		&cancelledEvent.ClubID,
+		&cancelledEvent.Title,
+		&cancelledEvent.Description,
+		&cancelledEvent.StartDate,
+		&cancelledEvent.EndDate,
+		&cancelledEvent.Tags,
+		&cancelledEvent.Location,
+		&cancelledEvent.Status,
+		&cancelledEvent.CreatedBy,
+		&cancelledEvent.CreatedAt,
+		&cancelledEvent.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &cancelledEvent, nil
+}
+
+// Records whether the user attends an active event, replacing an earlier
+// answer. It returns false when there is no such active event.
+func (e *EventRepository) SetAttendance(eventID, userID, situation string) (bool, error) {
+	now := time.Now()
+	result, err := e.db.Exec(`
+		INSERT INTO attended_events (user_id, event_id, situation, created_at, updated_at)
+		SELECT $1, e.id, $3, $4, $4
+		FROM events e
+		WHERE e.id = $2 AND e.status = $5 AND e.deleted_at IS NULL
+		ON CONFLICT (user_id, event_id) DO UPDATE
+		SET situation = EXCLUDED.situation, updated_at = EXCLUDED.updated_at`,
+		userID, eventID, situation, now, models.EventStatusActive,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
 	if err != nil {
-		return err
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (e *EventRepository) GetEventAttendees(eventID string) ([]models.EventAttendee, error) {
+	rows, err := e.db.Query(`
+		SELECT u.id, u.email, COALESCE(u.email_preferences, 'true')
+		FROM attended_events ae
+		JOIN users u ON u.id = ae.user_id
+		WHERE ae.event_id = $1 AND ae.situation = $2`,
+		eventID, models.AttendanceGoing,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attendees []models.EventAttendee
+	for rows.Next() {
+		var attendee models.EventAttendee
+		err := rows.Scan(
+			&attendee.UserID,
+			&attendee.Email,
+			&attendee.EmailPreferences,
+		)
+		if err != nil {
+			return nil, err
+		}
+		attendees = append(attendees, attendee)
 	}
-	return nil
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attendees, nil
 }
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type NotificationRepository struct {
+	db db.Conn
+}
+
+func NewNotificationRepository(db db.Conn) *NotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (n *NotificationRepository) CreateNotification(notification models.Notification) (string, error) {
+	data, err := json.Marshal(notification.Data)
+	if err != nil {
+		return "", err
+	}
+
+	var notificationID string
+	err = n.db.QueryRow(`
+		INSERT INTO notifications (user_id, club_id, type, title, body, data, created_at, updated_at)
+		VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $7)
+		RETURNING id`,
+		notification.UserID,
+		notification.ClubID,
+		notification.Type,
+		notification.Title,
+		notification.Body,
+		data,
+		time.Now(),
+	).Scan(&notificationID)
+	if err != nil {
+		return "", err
+	}
+
+	return notificationID, nil
+}
+
+func (n *NotificationRepository) ListNotifications(userID string, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	rows, err := n.db.Query(`
+		SELECT id, user_id, COALESCE(club_id::text, ''), type, title, body, data, read_at, created_at
+		FROM notifications
+		WHERE user_id = $1 AND ($2 = false OR read_at IS NULL)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`,
+		userID, unreadOnly, limit, offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var notification models.Notification
+		var data []byte
+		var readAt sql.NullString
+		err := rows.Scan(
+			&notification.ID,
+			&notification.UserID,
+			&notification.ClubID,
+			&notification.Type,
+			&notification.Title,
+			&notification.Body,
+			&data,
+			&readAt,
+			&notification.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &notification.Data); err != nil {
+				return nil, err
+			}
+		}
+		if readAt.Valid {
+			notification.ReadAt = &readAt.String
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (n *NotificationRepository) CountUnread(userID string) (int, error) {
+	var count int
+	err := n.db.QueryRow(`
+		SELECT COUNT(*) FROM notifications
+		WHERE user_id = $1 AND read_at IS NULL`,
+		userID,
+	).Scan(&count)
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Marks a single notification of the user as read. Returns false when the
+// notification does not exist or belongs to someone else.
+func (n *NotificationRepository) MarkAsRead(userID, notificationID string) (bool, error) {
+	result, err := n.db.Exec(`
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3), updated_at = $3
+		WHERE id = $1 AND user_id = $2`,
+		notificationID, userID, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Marks the given notifications of the user as read, or all unread ones when
+// notificationIDs is empty. Returns the number of notifications changed.
+func (n *NotificationRepository) MarkManyAsRead(userID string, notificationIDs []string) (int64, error) {
+	result, err := n.db.Exec(`
+		UPDATE notifications
+		SET read_at = $2, updated_at = $2
+		WHERE user_id = $1
+			AND read_at IS NULL
+			AND (COALESCE(cardinality($3::uuid[]), 0) = 0 OR id = ANY($3::uuid[]))`,
+		userID, time.Now(), pq.Array(notificationIDs),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
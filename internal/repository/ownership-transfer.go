@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"database/sql"
+	"time"
+)
+
+type OwnershipTransferRepository struct {
+	db db.Conn
+}
+
+func NewOwnershipTransferRepository(db db.Conn) *OwnershipTransferRepository {
+	return &OwnershipTransferRepository{
+		db: db,
+	}
+}
+
+// Cancels the transfer of the club that is waiting for an answer, if any.
+func (o *OwnershipTransferRepository) CancelPendingTransfer(clubID string) (bool, error) {
+	result, err := o.db.Exec(`
+		UPDATE club_ownership_transfers
+		SET status = $2, updated_at = $3
+		WHERE club_id = $1 AND status = $4`,
+		clubID, models.TransferStatusCancelled, time.Now(), models.TransferStatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (o *OwnershipTransferRepository) CreateTransfer(transfer models.OwnershipTransfer, ttl time.Duration) (*models.OwnershipTransfer, error) {
+	now := time.Now()
+	var created models.OwnershipTransfer
+	err := o.db.QueryRow(`
+		INSERT INTO club_ownership_transfers (club_id, from_user_id, to_user_id, stay_owner, status, expires_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $7)
+		RETURNING id, club_id, from_user_id, to_user_id, stay_owner, status, expires_at, created_at, updated_at`,
+		transfer.ClubID, transfer.FromUserID, transfer.ToUserID, transfer.StayOwner, models.TransferStatusPending, now.Add(ttl), now,
+	).Scan(
+		&created.ID,
+		&created.ClubID,
+		&created.FromUserID,
+		&created.ToUserID,
+		&created.StayOwner,
+		&created.Status,
+		&created.ExpiresAt,
+		&created.CreatedAt,
+		&created.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Returns the transfer of the club that is waiting for an answer, or nil.
+func (o *OwnershipTransferRepository) GetPendingTransfer(clubID string) (*models.OwnershipTransfer, error) {
+	var transfer models.OwnershipTransfer
+	err := o.db.QueryRow(`
+		SELECT id, club_id, from_user_id, to_user_id, stay_owner, status, expires_at, created_at, updated_at
+		FROM club_ownership_transfers
+		WHERE club_id = $1 AND status = $2 AND expires_at > $3`,
+		clubID, models.TransferStatusPending, time.Now(),
+	).Scan(
+		&transfer.ID,
+		&transfer.ClubID,
+		&transfer.FromUserID,
+		&transfer.ToUserID,
+		&transfer.StayOwner,
+		&transfer.Status,
+		&transfer.ExpiresAt,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// Lists the unexpired transfers offered to the user.
+func (o *OwnershipTransferRepository) ListPendingTransfersForUser(userID string) ([]models.OwnershipTransfer, error) {
+	rows, err := o.db.Query(`
+		SELECT t.id, t.club_id, c.name, t.from_user_id, t.to_user_id, t.stay_owner, t.status, t.expires_at, t.created_at, t.updated_at
+		FROM club_ownership_transfers t
+		JOIN clubs c ON c.id = t.club_id
+		WHERE t.to_user_id = $1 AND t.status = $2 AND t.expires_at > $3 AND c.deleted_at IS NULL
+		ORDER BY t.created_at DESC`,
+		userID, models.TransferStatusPending, time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transfers := []models.OwnershipTransfer{}
+	for rows.Next() {
+		var transfer models.OwnershipTransfer
+		err := rows.Scan(
+			&transfer.ID,
+			&transfer.ClubID,
+			&transfer.ClubName,
+			&transfer.FromUserID,
+			&transfer.ToUserID,
+			&transfer.StayOwner,
+			&transfer.Status,
+			&transfer.ExpiresAt,
+			&transfer.CreatedAt,
+			&transfer.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, transfer)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// Answers an unexpired pending transfer offered to the user with status. It
+// returns nil when there is no such transfer, so a transfer can only be
+// answered once.
+func (o *OwnershipTransferRepository) AnswerTransfer(transferID, userID, status string) (*models.OwnershipTransfer, error) {
+	now := time.Now()
+	var transfer models.OwnershipTransfer
+	err := o.db.QueryRow(`
+		UPDATE club_ownership_transfers
+		SET status = $3, updated_at = $4
+		WHERE id = $1 AND to_user_id = $2 AND status = $5 AND expires_at > $4
+		RETURNING id, club_id, from_user_id, to_user_id, stay_owner, status, expires_at, created_at, updated_at`,
+		transferID, userID, status, now, models.TransferStatusPending,
+	).Scan(
+		&transfer.ID,
+		&transfer.ClubID,
+		&transfer.FromUserID,
+		&transfer.ToUserID,
+		&transfer.StayOwner,
+		&transfer.Status,
+		&transfer.ExpiresAt,
+		&transfer.CreatedAt,
+		&transfer.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"database/sql"
+	"time"
+)
+
+// PlatformRepository holds the queries of platform staff, which span all
+// clubs and users.
+type PlatformRepository struct {
+	db db.Conn
+}
+
+func NewPlatformRepository(db db.Conn) *PlatformRepository {
+	return &PlatformRepository{
+		db: db,
+	}
+}
+
+// GetUserAccount returns the platform role of a user, empty for most users,
+// and whether they are suspended. Unknown users have neither.
+func (p *PlatformRepository) GetUserAccount(userID string) (string, bool, error) {
+	var platformRole string
+	var suspended bool
+	err := p.db.QueryRow(`
+		SELECT COALESCE(platform_role, ''), suspended_at IS NOT NULL
+		FROM users
+		WHERE id = $1`,
+		userID,
+	).Scan(&platformRole, &suspended)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return platformRole, suspended, nil
+}
+
+// SetPlatformRole gives the user a platform role, or takes it away when role
+// is empty. It returns false when the user does not exist.
+func (p *PlatformRepository) SetPlatformRole(userID string, role string) (bool, error) {
+	result, err := p.db.Exec(`
+		UPDATE users
+		SET platform_role = NULLIF($2, ''), updated_at = $3
+		WHERE id = $1`,
+		userID, role, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Returns the users holding a platform role.
+func (p *PlatformRepository) ListPlatformRoles() ([]models.PlatformRoleAssignment, error) {
+	rows, err := p.db.Query(`
+		SELECT id, email, platform_role
+		FROM users
+		WHERE platform_role IS NOT NULL
+		ORDER BY platform_role, email`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assignments []models.PlatformRoleAssignment
+	for rows.Next() {
+		var assignment models.PlatformRoleAssignment
+		if err := rows.Scan(&assignment.UserID, &assignment.Email, &assignment.Role); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
+
+// Returns the clubs matching filter, suspended ones included, newest first.
+func (p *PlatformRepository) ListClubs(filter models.AdminClubFilter) ([]models.AdminClub, error) {
+	rows, err := p.db.Query(`
+		SELECT c.id, c.name, c.description, c.email, c.member_count, c.status, c.verified_at IS NOT NULL,
+			c.created_at, c.updated_at,
+			(SELECT count(DISTINCT user_id) FROM club_roles WHERE club_id = c.id),
+			COALESCE(c.status_reason, ''), COALESCE(c.status_changed_by, ''), c.status_changed_at
+		FROM clubs c
+		WHERE c.deleted_at IS NULL
+			AND ($1 = '' OR c.status = $1)
+			AND ($2 = '' OR c.name ILIKE '%' || $2 || '%' OR c.email ILIKE '%' || $2 || '%')
+		ORDER BY c.created_at DESC, c.id
+		LIMIT $3 OFFSET $4`,
+		filter.Status,
+		filter.Search,
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clubs := []models.AdminClub{}
+	for rows.Next() {
+		var club models.AdminClub
+		err := rows.Scan(
+			&club.ID,
+			&club.Name,
+			&club.Description,
+			&club.Email,
+			&club.MemberCount,
+			&club.Status,
+			&club.Verified,
+			&club.CreatedAt,
+			&club.UpdatedAt,
+			&club.Members,
+			&club.StatusReason,
+			&club.StatusChangedBy,
+			&club.StatusChangedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clubs = append(clubs, club)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return clubs, nil
+}
+
+// SuspendUser suspends a user, or lifts their suspension when suspended is
+// false. It returns false when the user does not exist or already is in that
+// state.
+func (p *PlatformRepository) SuspendUser(userID string, suspended bool, reason string) (bool, error) {
+	result, err := p.db.Exec(`
+		UPDATE users
+		SET suspended_at = CASE WHEN $2 THEN $4::timestamp END,
+			suspension_reason = CASE WHEN $2 THEN NULLIF($3, '') END,
+			updated_at = $4
+		WHERE id = $1 AND (suspended_at IS NOT NULL) <> $2`,
+		userID, suspended, reason, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (p *PlatformRepository) GetStats() (*models.PlatformStats, error) {
+	var stats models.PlatformStats
+	err := p.db.QueryRow(`
+		SELECT
+			(SELECT count(*) FROM users),
+			(SELECT count(*) FROM users WHERE suspended_at IS NOT NULL),
+			(SELECT count(*) FROM clubs WHERE deleted_at IS NULL),
+			(SELECT count(*) FROM clubs WHERE deleted_at IS NULL AND status = $2),
+			(SELECT count(*) FROM clubs WHERE deleted_at IS NULL AND status = $3),
+			(SELECT count(*) FROM clubs WHERE deleted_at IS NULL AND verified_at IS NOT NULL),
+			(SELECT count(DISTINCT (cr.user_id, cr.club_id)) FROM club_roles cr JOIN clubs c ON c.id = cr.club_id WHERE c.deleted_at IS NULL),
+			(SELECT count(*) FROM events WHERE deleted_at IS NULL),
+			(SELECT count(*) FROM events WHERE deleted_at IS NULL AND start_date > $1),
+			(SELECT count(*) FROM feed_posts WHERE deleted_at IS NULL)`,
+		time.Now(), models.ClubStatusPending, models.ClubStatusSuspended,
+	).Scan(
+		&stats.Users,
+		&stats.SuspendedUsers,
+		&stats.Clubs,
+		&stats.PendingClubs,
+		&stats.SuspendedClubs,
+		&stats.VerifiedClubs,
+		&stats.Memberships,
+		&stats.Events,
+		&stats.UpcomingEvents,
+		&stats.Posts,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
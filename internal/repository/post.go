@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"api/internal/models"
+	db "api/pkg/database"
+)
+
+type PostRepository struct {
+	db db.Conn
+}
+
+func NewPostRepository(db db.Conn) *PostRepository {
+	return &PostRepository{
+		db: db,
+	}
+}
+
+func (p *PostRepository) CreatePost(post *models.Post) (*models.Post, error) {
+	var newPost models.Post
+	err := p.db.QueryRow(`
+		INSERT INTO feed_posts (author_club_id, author_user_id, iamge, description, like_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, '0', $5, $5)
+		RETURNING id, author_club_id, author_user_id, iamge, description, like_count, created_at, updated_at`,
+		post.ClubID, post.UserID, post.Image, post.Description, time.Now(),
+	).Scan(
+		&newPost.ID,
+		&newPost.ClubID,
+		&newPost.UserID,
+		&newPost.Image,
+		&newPost.Description,
+		&newPost.LikeCount,
+		&newPost.CreatedAt,
+		&newPost.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &newPost, nil
+}
+
+func (p *PostRepository) GetAllPosts() ([]models.Post, error) {
+	rows, err := p.db.Query(`
+		SELECT p.id, p.author_club_id, p.author_user_id, p.iamge, p.description, p.like_count, p.created_at, p.updated_at
+		FROM feed_posts p
+		JOIN clubs c ON c.id = p.author_club_id
+		WHERE p.deleted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY p.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		err := rows.Scan(
+			&post.ID,
+			&post.ClubID,
+			&post.UserID,
+			&post.Image,
+			&post.Description,
+			&post.LikeCount,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// Moves a post of the club to the trash. It returns false when the club has
+// no such post.
+func (p *PostRepository) DeletePost(clubID, postID string) (bool, error) {
+	result, err := p.db.Exec(`
+		UPDATE feed_posts
+		SET deleted_at = $3
+		WHERE id = $1 AND author_club_id = $2 AND deleted_at IS NULL`,
+		postID, clubID, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Returns a post of the club that is in the trash, nil when the club has no
+// such post in the trash.
+func (p *PostRepository) GetTrashedPost(clubID, postID string) (*models.Post, error) {
+	var post models.Post
+	err := p.db.QueryRow(`
+		SELECT id, author_club_id, author_user_id, iamge, description, like_count, created_at, updated_at
+		FROM feed_posts
+		WHERE id = $1 AND author_club_id = $2 AND deleted_at IS NOT NULL`,
+		postID, clubID,
+	).Scan(
+		&post.ID,
+		&post.ClubID,
+		&post.UserID,
+		&post.Image,
+		&post.Description,
+		&post.LikeCount,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &post, nil
+}
+
+// Takes a post of the club out of the trash. It returns false when the club
+// has no such post in the trash.
+func (p *PostRepository) RestorePost(clubID, postID string) (bool, error) {
+	result, err := p.db.Exec(`
+		UPDATE feed_posts
+		SET deleted_at = NULL, updated_at = $3
+		WHERE id = $1 AND author_club_id = $2 AND deleted_at IS NOT NULL`,
+		postID, clubID, time.Now(),
+	)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// Permanently deletes the posts moved to the trash before the given time,
+// along with their likes and comments.
+func (p *PostRepository) PurgeDeletedPosts(before time.Time) (int64, error) {
+	for _, stmt := range []string{
+		`DELETE FROM likes WHERE post_id IN (SELECT id FROM feed_posts WHERE deleted_at < $1)`,
+		`DELETE FROM comments WHERE post_id IN (SELECT id FROM feed_posts WHERE deleted_at < $1)`,
+	} {
+		if _, err := p.db.Exec(stmt, before); err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := p.db.Exec(`DELETE FROM feed_posts WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	db "api/pkg/database"
+	"time"
+)
+
+type RateLimitRepository struct {
+	db db.Conn
+}
+
+func NewRateLimitRepository(db db.Conn) *RateLimitRepository {
+	return &RateLimitRepository{
+		db: db,
+	}
+}
+
+// Refills the bucket of key by rate tokens per second up to burst and takes
+// one token from it if there is one. The database clock is used so every
+// instance refills alike. It returns the tokens left and whether a token
+// was taken.
+func (r *RateLimitRepository) TakeToken(key string, burst, rate float64) (float64, bool, error) {
+	var tokens float64
+	var allowed bool
+	err := r.db.QueryRow(`
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::double precision - 1, true, now())
+		ON CONFLICT (key) DO UPDATE
+		SET tokens = CASE
+				WHEN LEAST($2, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::double precision) >= 1
+				THEN LEAST($2, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::double precision) - 1
+				ELSE LEAST($2, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::double precision)
+			END,
+			allowed = LEAST($2, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at), 0) * $3::double precision) >= 1,
+			updated_at = now()
+		RETURNING tokens, allowed`,
+		key, burst, rate,
+	).Scan(&tokens, &allowed)
+	return tokens, allowed, err
+}
+
+func (r *RateLimitRepository) DeleteIdleBuckets(idle time.Duration) error {
+	_, err := r.db.Exec(`
+		DELETE FROM rate_limit_buckets
+		WHERE updated_at < now() - make_interval(secs => $1)`,
+		idle.Seconds(),
+	)
+	return err
+}
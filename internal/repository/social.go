@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const socialPostColumns = `id, club_id, account_id, author_user_id, event_id, content, image, status, scheduled_at, published_at, external_id, last_error, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSocialPost(row rowScanner) (*models.SocialPost, error) {
+	var post models.SocialPost
+	err := row.Scan(
+		&post.ID,
+		&post.ClubID,
+		&post.AccountID,
+		&post.UserID,
+		&post.EventID,
+		&post.Content,
+		&post.Image,
+		&post.Status,
+		&post.ScheduledAt,
+		&post.PublishedAt,
+		&post.ExternalID,
+		&post.LastError,
+		&post.CreatedAt,
+		&post.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+type SocialRepository struct {
+	db db.Conn
+}
+
+func NewSocialRepository(db db.Conn) *SocialRepository {
+	return &SocialRepository{
+		db: db,
+	}
+}
+
+func (s *SocialRepository) CreateAccount(account models.SocialAccount) (*models.SocialAccount, error) {
+	config, err := json.Marshal(account.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var newAccount models.SocialAccount
+	err = s.db.QueryRow(`
+		INSERT INTO social_accounts (club_id, platform, name, config, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING id, club_id, platform, name, created_at, updated_at`,
+		account.ClubID, account.Platform, account.Name, config, time.Now(),
+	).Scan(
+		&newAccount.ID,
+		&newAccount.ClubID,
+		&newAccount.Platform,
+		&newAccount.Name,
+		&newAccount.CreatedAt,
+		&newAccount.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &newAccount, nil
+}
+
+// Returns the account including its connector config if it belongs to the
+// club.
+func (s *SocialRepository) GetAccount(clubID, accountID string) (*models.SocialAccount, error) {
+	var account models.SocialAccount
+	var config []byte
+	err := s.db.QueryRow(`
+		SELECT id, club_id, platform, name, config, created_at, updated_at
+		FROM social_accounts
+		WHERE id = $1 AND club_id = $2`,
+		accountID, clubID,
+	).Scan(
+		&account.ID,
+		&account.ClubID,
+		&account.Platform,
+		&account.Name,
+		&config,
+		&account.CreatedAt,
+		&account.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if len(config) > 0 {
+		if err := json.Unmarshal(config, &account.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	return &account, nil
+}
+
+func (s *SocialRepository) ListAccounts(clubID string) ([]models.SocialAccount, error) {
+	rows, err := s.db.Query(`
+		SELECT id, club_id, platform, name, created_at, updated_at
+		FROM social_accounts
+		WHERE club_id = $1
+		ORDER BY created_at`,
+		clubID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.SocialAccount{}
+	for rows.Next() {
+		var account models.SocialAccount
+		err := rows.Scan(
+			&account.ID,
+			&account.ClubID,
+			&account.Platform,
+			&account.Name,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (s *SocialRepository) DeleteAccount(clubID, accountID string) error {
+	_, err := s.db.Exec(`DELETE FROM social_accounts WHERE id = $1 AND club_id = $2`, accountID, clubID)
+	return err
+}
+
+func (s *SocialRepository) CreatePost(post models.SocialPost) (*models.SocialPost, error) {
+	row := s.db.QueryRow(`
+		INSERT INTO social_posts (club_id, account_id, author_user_id, event_id, content, image, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+		RETURNING `+socialPostColumns,
+		post.ClubID, post.AccountID, post.UserID, post.EventID, post.Content, post.Image, models.SocialPostDraft, time.Now(),
+	)
+	return scanSocialPost(row)
+}
+
+func (s *SocialRepository) GetPost(clubID, postID string) (*models.SocialPost, error) {
+	row := s.db.QueryRow(`
+		SELECT `+socialPostColumns+`
+		FROM social_posts
+		WHERE id = $1 AND club_id = $2`,
+		postID, clubID,
+	)
+	post, err := scanSocialPost(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return post, err
+}
+
+// Lists the posts of a club, optionally filtered by status.
+func (s *SocialRepository) ListPosts(clubID, status string) ([]models.SocialPost, error) {
+	rows, err := s.db.Query(`
+		SELECT `+socialPostColumns+`
+		FROM social_posts
+		WHERE club_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY COALESCE(scheduled_at, created_at) DESC`,
+		clubID, status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []models.SocialPost{}
+	for rows.Next() {
+		post, err := scanSocialPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+// Updates the content of a post that was not published yet. Returns false
+// when no such post exists.
+func (s *SocialRepository) UpdatePost(clubID, postID, content, image string) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE social_posts
+		SET content = $1, image = $2, updated_at = $3
+		WHERE id = $4 AND club_id = $5 AND status IN ($6, $7, $8)`,
+		content, image, time.Now(), postID, clubID,
+		models.SocialPostDraft, models.SocialPostScheduled, models.SocialPostFailed,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// Schedules a post that was not published yet for publishing at the given
+// time. Returns false when no such post exists.
+func (s *SocialRepository) SchedulePost(clubID, postID string, scheduledAt time.Time) (bool, error) {
+	result, err := s.db.Exec(`
+		UPDATE social_posts
+		SET status = $1, scheduled_at = $2, last_error = NULL, updated_at = $3
+		WHERE id = $4 AND club_id = $5 AND status IN ($6, $1, $7)`,
+		models.SocialPostScheduled, scheduledAt, time.Now(), postID, clubID,
+		models.SocialPostDraft, models.SocialPostFailed,
+	)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+func (s *SocialRepository) DeletePost(clubID, postID string) error {
+	_, err := s.db.Exec(`DELETE FROM social_posts WHERE id = $1 AND club_id = $2`, postID, clubID)
+	return err
+}
+
+// Moves due scheduled posts to publishing for the length of lease and
+// returns them. Posts whose lease expired without an outcome being recorded,
+// e.g. because the instance publishing them crashed, are claimed again. Rows
+// locked by another instance are skipped. Posts of clubs that are not
+// active, e.g. suspended or in the trash, wait until the club is active again.
+func (s *SocialRepository) ClaimDuePosts(limit int, lease time.Duration) ([]models.SocialPost, error) {
+	now := time.Now()
+	rows, err := s.db.Query(`
+		UPDATE social_posts
+		SET status = $1, claimed_until = $2, updated_at = $3
+		WHERE id IN (
+			SELECT p.id FROM social_posts p
+			JOIN clubs c ON c.id = p.club_id
+			WHERE ((p.status = $4 AND p.scheduled_at <= $3) OR (p.status = $1 AND p.claimed_until <= $3))
+				AND c.status = $6 AND c.deleted_at IS NULL
+			ORDER BY p.scheduled_at
+			LIMIT $5
+			FOR UPDATE OF p SKIP LOCKED
+		)
+		RETURNING `+socialPostColumns,
+		models.SocialPostPublishing, now.Add(lease), now, models.SocialPostScheduled, limit, models.ClubStatusActive,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.SocialPost
+	for rows.Next() {
+		post, err := scanSocialPost(rows)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, *post)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (s *SocialRepository) MarkPostPublished(postID, externalID string) error {
+	now := time.Now()
+	_, err := s.db.Exec(`
+		UPDATE social_posts
+		SET status = $1, external_id = NULLIF($2, ''), published_at = $3, last_error = NULL, claimed_until = NULL, updated_at = $3
+		WHERE id = $4`,
+		models.SocialPostPublished, externalID, now, postID,
+	)
+	return err
+}
+
+func (s *SocialRepository) MarkPostFailed(postID, errMessage string) error {
+	_, err := s.db.Exec(`
+		UPDATE social_posts
+		SET status = $1, last_error = $2, claimed_until = NULL, updated_at = $3
+		WHERE id = $4`,
+		models.SocialPostFailed, errMessage, time.Now(), postID,
+	)
+	return err
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+)
+
+type TrashRepository struct {
+	db db.Conn
+}
+
+func NewTrashRepository(db db.Conn) *TrashRepository {
+	return &TrashRepository{
+		db: db,
+	}
+}
+
+// Lists the deleted events and posts of a club, and the club itself when it
+// was deleted, most recently deleted first.
+func (t *TrashRepository) ListClubTrash(clubID string) ([]models.TrashItem, error) {
+	rows, err := t.db.Query(`
+		SELECT $2::varchar, id, COALESCE(name, ''), deleted_at
+		FROM clubs
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT $3::varchar, id, COALESCE(title, ''), deleted_at
+		FROM events
+		WHERE club_id = $1 AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT $4::varchar, id, COALESCE(left(description, 100), ''), deleted_at
+		FROM feed_posts
+		WHERE author_club_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`,
+		clubID, models.TrashTypeClub, models.TrashTypeEvent, models.TrashTypePost,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.TrashItem{}
+	for rows.Next() {
+		var item models.TrashItem
+		err := rows.Scan(
+			&item.Type,
+			&item.ID,
+			&item.Title,
+			&item.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	db "api/pkg/database"
+)
+
+// UnitOfWork hands out repositories that share one transaction, so changes
+// spread over several repositories are committed or rolled back together.
+type UnitOfWork struct {
+	tx db.Conn
+}
+
+// RunInTx runs fn in a new transaction. The transaction is committed when fn
+// returns nil and rolled back otherwise, the repositories of uow must not be
+// used after fn returns.
+func RunInTx(ctx context.Context, sqlDB *sql.DB, fn func(uow *UnitOfWork) error) error {
+	return db.InTx(ctx, sqlDB, func(tx db.Conn) error {
+		return fn(&UnitOfWork{tx: tx})
+	})
+}
+
+// Conn returns the transaction itself, for code that builds its own
+// repositories such as the audit log.
+func (u *UnitOfWork) Conn() db.Conn {
+	return u.tx
+}
+
+func (u *UnitOfWork) Audit() *AuditRepository {
+	return NewAuditRepository(u.tx)
+}
+
+func (u *UnitOfWork) Clubs() *ClubRepository {
+	return NewClubRepository(u.tx)
+}
+
+func (u *UnitOfWork) ClubUsers() *ClubUserRepository {
+	return NewClubUserRepository(u.tx)
+}
+
+func (u *UnitOfWork) Events() *EventRepository {
+	return NewEventRepository(u.tx)
+}
+
+func (u *UnitOfWork) EventReminders() *EventReminderRepository {
+	return NewEventReminderRepository(u.tx)
+}
+
+func (u *UnitOfWork) Notifications() *NotificationRepository {
+	return NewNotificationRepository(u.tx)
+}
+
+func (u *UnitOfWork) OwnershipTransfers() *OwnershipTransferRepository {
+	return NewOwnershipTransferRepository(u.tx)
+}
+
+func (u *UnitOfWork) Posts() *PostRepository {
+	return NewPostRepository(u.tx)
+}
+
+func (u *UnitOfWork) Social() *SocialRepository {
+	return NewSocialRepository(u.tx)
+}
+
+func (u *UnitOfWork) Trash() *TrashRepository {
+	return NewTrashRepository(u.tx)
+}
+
+func (u *UnitOfWork) Users() *UserRepository {
+	return NewUserRepository(u.tx)
+}
+
+func (u *UnitOfWork) Webhooks() *WebhookRepository {
+	return NewWebhookRepository(u.tx)
+}
@@ -4,13 +4,14 @@ import (
 	"database/sql"
 
 	"api/internal/models"
+	db "api/pkg/database"
 )
 
 type UserRepository struct {
-	db *sql.DB
+	db db.Conn
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
+func NewUserRepository(db db.Conn) *UserRepository {
 	return &UserRepository{
 		db: db,
 	}
@@ -0,0 +1,324 @@
+package repository
+
+import (
+	"api/internal/models"
+	db "api/pkg/database"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type WebhookRepository struct {
+	db db.Conn
+}
+
+func NewWebhookRepository(db db.Conn) *WebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+func (w *WebhookRepository) CreateWebhook(webhook models.Webhook) (*models.Webhook, error) {
+	var newWebhook models.Webhook
+	err := w.db.QueryRow(`
+		INSERT INTO webhooks (club_id, url, secret, topics, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, true, $5, $5)
+		RETURNING id, club_id, url, secret, topics, active, created_at, updated_at`,
+		webhook.ClubID, webhook.URL, webhook.Secret, pq.Array(webhook.Topics), time.Now(),
+	).Scan(
+		&newWebhook.ID,
+		&newWebhook.ClubID,
+		&newWebhook.URL,
+		&newWebhook.Secret,
+		pq.Array(&newWebhook.Topics),
+		&newWebhook.Active,
+		&newWebhook.CreatedAt,
+		&newWebhook.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &newWebhook, nil
+}
+
+// Returns the webhook only if it belongs to the club. The secret is not
+// included.
+func (w *WebhookRepository) GetWebhook(clubID, webhookID string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := w.db.QueryRow(`
+		SELECT id, club_id, url, topics, active, created_at, updated_at
+		FROM webhooks
+		WHERE id = $1 AND club_id = $2`,
+		webhookID, clubID,
+	).Scan(
+		&webhook.ID,
+		&webhook.ClubID,
+		&webhook.URL,
+		pq.Array(&webhook.Topics),
+		&webhook.Active,
+		&webhook.CreatedAt,
+		&webhook.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (w *WebhookRepository) ListWebhooks(clubID string) ([]models.Webhook, error) {
+	rows, err := w.db.Query(`
+		SELECT id, club_id, url, topics, active, created_at, updated_at
+		FROM webhooks
+		WHERE club_id = $1
+		ORDER BY created_at DESC`,
+		clubID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		var webhook models.Webhook
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.ClubID,
+			&webhook.URL,
+			pq.Array(&webhook.Topics),
+			&webhook.Active,
+			&webhook.CreatedAt,
+			&webhook.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (w *WebhookRepository) UpdateWebhook(webhook models.Webhook) error {
+	_, err := w.db.Exec(`
+		UPDATE webhooks
+		SET url = $1, topics = $2, active = $3, updated_at = $4
+		WHERE id = $5 AND club_id = $6`,
+		webhook.URL, pq.Array(webhook.Topics), webhook.Active, time.Now(), webhook.ID, webhook.ClubID,
+	)
+	return err
+}
+
+func (w *WebhookRepository) DeleteWebhook(clubID, webhookID string) error {
+	_, err := w.db.Exec(`DELETE FROM webhooks WHERE id = $1 AND club_id = $2`, webhookID, clubID)
+	return err
+}
+
+// Returns the ids of the active webhooks of the club subscribed to topic,
+// none when the club is in the trash.
+func (w *WebhookRepository) GetSubscribedWebhookIDs(clubID, topic string) ([]string, error) {
+	rows, err := w.db.Query(`
+		SELECT wh.id FROM webhooks wh
+		JOIN clubs c ON c.id = wh.club_id
+		WHERE wh.club_id = $1 AND wh.active = true AND $2 = ANY(wh.topics) AND c.deleted_at IS NULL`,
+		clubID, topic,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhookIDs []string
+	for rows.Next() {
+		var webhookID string
+		if err := rows.Scan(&webhookID); err != nil {
+			return nil, err
+		}
+		webhookIDs = append(webhookIDs, webhookID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return webhookIDs, nil
+}
+
+func (w *WebhookRepository) CreateDelivery(webhookID, topic string, payload []byte) (string, error) {
+	var deliveryID string
+	now := time.Now()
+	err := w.db.QueryRow(`
+		INSERT INTO webhook_deliveries (webhook_id, topic, payload, status, attempts, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 0, $5, $5, $5)
+		RETURNING id`,
+		webhookID, topic, payload, models.WebhookDeliveryPending, now,
+	).Scan(&deliveryID)
+	if err != nil {
+		return "", err
+	}
+	return deliveryID, nil
+}
+
+// Returns the delivery only if its webhook belongs to the club.
+func (w *WebhookRepository) GetDelivery(clubID, deliveryID string) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	err := w.db.QueryRow(`
+		SELECT d.id, d.webhook_id, d.topic, d.payload, d.status, d.attempts, d.next_attempt_at, d.last_status_code, d.last_error, d.delivered_at, d.created_at
+		FROM webhook_deliveries d
+		JOIN webhooks wh ON wh.id = d.webhook_id
+		WHERE d.id = $1 AND wh.club_id = $2`,
+		deliveryID, clubID,
+	).Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Topic,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	delivery.Payload = payload
+	return &delivery, nil
+}
+
+func (w *WebhookRepository) ListDeliveries(webhookID string, limit int) ([]models.WebhookDelivery, error) {
+	rows, err := w.db.Query(`
+		SELECT id, webhook_id, topic, status, attempts, next_attempt_at, last_status_code, last_error, delivered_at, created_at
+		FROM webhook_deliveries
+		WHERE webhook_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2`,
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var delivery models.WebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.WebhookID,
+			&delivery.Topic,
+			&delivery.Status,
+			&delivery.Attempts,
+			&delivery.NextAttemptAt,
+			&delivery.LastStatusCode,
+			&delivery.LastError,
+			&delivery.DeliveredAt,
+			&delivery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Claims due deliveries of active webhooks. Claimed rows are leased by
+// moving next_attempt_at forward so other instances skip them while the
+// request is in flight.
+func (w *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.PendingWebhookDelivery, error) {
+	now := time.Now()
+	rows, err := w.db.Query(`
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $1, updated_at = $2
+		FROM webhooks wh
+		WHERE d.webhook_id = wh.id
+			AND d.id IN (
+				SELECT due.id
+				FROM webhook_deliveries due
+				JOIN webhooks hook ON hook.id = due.webhook_id
+				WHERE due.status = $3 AND due.next_attempt_at <= $2 AND hook.active = true
+				ORDER BY due.next_attempt_at
+				LIMIT $4
+				FOR UPDATE OF due SKIP LOCKED
+			)
+		RETURNING d.id, d.topic, d.payload, d.attempts, wh.url, wh.secret`,
+		now.Add(lease), now, models.WebhookDeliveryPending, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.PendingWebhookDelivery
+	for rows.Next() {
+		var delivery models.PendingWebhookDelivery
+		err := rows.Scan(
+			&delivery.ID,
+			&delivery.Topic,
+			&delivery.Payload,
+			&delivery.Attempts,
+			&delivery.URL,
+			&delivery.Secret,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (w *WebhookRepository) MarkDeliverySucceeded(deliveryID string, statusCode int) error {
+	now := time.Now()
+	_, err := w.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = $2, last_error = NULL,
+			next_attempt_at = NULL, delivered_at = $3, updated_at = $3
+		WHERE id = $4`,
+		models.WebhookDeliverySucceeded, statusCode, now, deliveryID,
+	)
+	return err
+}
+
+// Records a failed attempt. A nil nextAttemptAt marks the delivery as
+// permanently failed.
+func (w *WebhookRepository) MarkDeliveryAttemptFailed(deliveryID string, statusCode int, errMessage string, nextAttemptAt *time.Time) error {
+	status := models.WebhookDeliveryPending
+	if nextAttemptAt == nil {
+		status = models.WebhookDeliveryFailed
+	}
+
+	_, err := w.db.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = attempts + 1, last_status_code = NULLIF($2, 0), last_error = $3,
+			next_attempt_at = $4, updated_at = $5
+		WHERE id = $6`,
+		status, statusCode, errMessage, nextAttemptAt, time.Now(), deliveryID,
+	)
+	return err
+}
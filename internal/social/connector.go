@@ -0,0 +1,155 @@
+package social
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"api/internal/models"
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+)
+
+const (
+	pollInterval = 30 * time.Second
+	batchSize    = 20
+	// Posts not marked published or failed within leaseTime are claimed
+	// again. A post may then be published twice, which is preferred over
+	// losing it.
+	leaseTime = 5 * time.Minute
+)
+
+// Connector publishes posts to one external platform.
+type Connector interface {
+	Platform() string
+	// Validate checks the account config before the account is stored.
+	Validate(ctx context.Context, config map[string]string) error
+	// Publish sends the post and returns the id the platform assigned to it.
+	Publish(ctx context.Context, account models.SocialAccount, post models.SocialPost) (string, error)
+}
+
+// store is the part of the social repository the publisher works with,
+// replaced by an in-memory one in tests.
+type store interface {
+	ClaimDuePosts(limit int, lease time.Duration) ([]models.SocialPost, error)
+	MarkPostPublished(postID, externalID string) error
+	MarkPostFailed(postID, errMessage string) error
+	GetAccount(clubID, accountID string) (*models.SocialAccount, error)
+}
+
+// Publisher publishes scheduled posts through the connector of their
+// account's platform.
+type Publisher struct {
+	store      func(ctx context.Context) store
+	connectors map[string]Connector
+}
+
+func NewPublisher(sqlDB *sql.DB, connectors ...Connector) *Publisher {
+	return newPublisher(func(ctx context.Context) store {
+		return repository.NewSocialRepository(db.WithContext(ctx, sqlDB))
+	}, connectors...)
+}
+
+func newPublisher(store func(ctx context.Context) store, connectors ...Connector) *Publisher {
+	p := &Publisher{
+		store:      store,
+		connectors: make(map[string]Connector, len(connectors)),
+	}
+	for _, connector := range connectors {
+		p.connectors[connector.Platform()] = connector
+	}
+	return p
+}
+
+func (p *Publisher) Connector(platform string) (Connector, bool) {
+	connector, ok := p.connectors[platform]
+	return connector, ok
+}
+
+func (p *Publisher) Platforms() []string {
+	platforms := make([]string, 0, len(p.connectors))
+	for platform := range p.connectors {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+func (p *Publisher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.publishDuePosts(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to publish social posts", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Publisher) publishDuePosts(ctx context.Context) error {
+	// Claimed posts are marked published or failed even while shutting down,
+	// otherwise they would stay in the publishing state.
+	socialRepository := p.store(context.WithoutCancel(ctx))
+
+	for {
+		posts, err := socialRepository.ClaimDuePosts(batchSize, leaseTime)
+		if err != nil {
+			return err
+		}
+
+		for _, post := range posts {
+			externalID, err := p.publish(ctx, post)
+			if err == nil {
+				err = socialRepository.MarkPostPublished(post.ID, externalID)
+			} else {
+				err = socialRepository.MarkPostFailed(post.ID, err.Error())
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to record social post status", "post_id", post.ID, "error", err)
+			}
+		}
+
+		if len(posts) < batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (p *Publisher) publish(ctx context.Context, post models.SocialPost) (string, error) {
+	account, err := p.store(ctx).GetAccount(post.ClubID, post.AccountID)
+	if err != nil {
+		return "", err
+	}
+	if account == nil {
+		return "", fmt.Errorf("account %s not found", post.AccountID)
+	}
+
+	connector, ok := p.connectors[account.Platform]
+	if !ok {
+		return "", fmt.Errorf("no connector for platform %s", account.Platform)
+	}
+
+	return connector.Publish(ctx, *account, post)
+}
+
+// PostFromEvent drafts the content of a post announcing an event.
+func PostFromEvent(event models.Event) string {
+	content := event.Title
+	if event.Description != "" {
+		content += "\n\n" + event.Description
+	}
+	content += "\n\nWhen: " + event.StartDate
+	if event.Location != "" {
+		content += "\nWhere: " + event.Location
+	}
+	return content
+}
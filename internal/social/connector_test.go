@@ -0,0 +1,239 @@
+package social
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"api/internal/models"
+)
+
+// memoryStore keeps posts in memory and moves them between statuses like
+// the social repository does.
+type memoryStore struct {
+	mu       sync.Mutex
+	posts    map[string]*memoryPost
+	accounts map[string]models.SocialAccount
+}
+
+type memoryPost struct {
+	post         models.SocialPost
+	scheduledAt  time.Time
+	claimedUntil time.Time
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		posts: map[string]*memoryPost{},
+		accounts: map[string]models.SocialAccount{
+			"fake-account": {ID: "fake-account", ClubID: "club-1", Platform: "fake"},
+			"gone-account": {ID: "gone-account", ClubID: "club-1", Platform: "gone"},
+		},
+	}
+}
+
+func (m *memoryStore) add(id, accountID, status string, scheduledAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.posts[id] = &memoryPost{
+		post:        models.SocialPost{ID: id, ClubID: "club-1", AccountID: accountID, Status: status},
+		scheduledAt: scheduledAt,
+	}
+}
+
+// schedule is what the schedule endpoint does to a draft.
+func (m *memoryStore) schedule(id string, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.posts[id].post.Status = models.SocialPostScheduled
+	m.posts[id].scheduledAt = at
+}
+
+func (m *memoryStore) get(id string) models.SocialPost {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.posts[id].post
+}
+
+func (m *memoryStore) ClaimDuePosts(limit int, lease time.Duration) ([]models.SocialPost, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	var due []*memoryPost
+	for _, p := range m.posts {
+		switch {
+		case p.post.Status == models.SocialPostScheduled && !p.scheduledAt.After(now):
+			due = append(due, p)
+		case p.post.Status == models.SocialPostPublishing && !p.claimedUntil.After(now):
+			due = append(due, p)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].scheduledAt.Before(due[j].scheduledAt) })
+
+	var claimed []models.SocialPost
+	for _, p := range due {
+		if len(claimed) == limit {
+			break
+		}
+		p.post.Status = models.SocialPostPublishing
+		p.claimedUntil = now.Add(lease)
+		claimed = append(claimed, p.post)
+	}
+	return claimed, nil
+}
+
+func (m *memoryStore) MarkPostPublished(postID, externalID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.posts[postID]
+	p.post.Status = models.SocialPostPublished
+	p.post.ExternalID = &externalID
+	p.post.LastError = nil
+	p.claimedUntil = time.Time{}
+	return nil
+}
+
+func (m *memoryStore) MarkPostFailed(postID, errMessage string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := m.posts[postID]
+	p.post.Status = models.SocialPostFailed
+	p.post.LastError = &errMessage
+	p.claimedUntil = time.Time{}
+	return nil
+}
+
+func (m *memoryStore) GetAccount(clubID, accountID string) (*models.SocialAccount, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	account, ok := m.accounts[accountID]
+	if !ok || account.ClubID != clubID {
+		return nil, nil
+	}
+	return &account, nil
+}
+
+// observingConnector records the status of each post in the store while the
+// fake connector publishes it.
+type observingConnector struct {
+	*FakeConnector
+	store    *memoryStore
+	statuses map[string]string
+}
+
+func (o *observingConnector) Publish(ctx context.Context, account models.SocialAccount, post models.SocialPost) (string, error) {
+	o.statuses[post.ID] = o.store.get(post.ID).Status
+	return o.FakeConnector.Publish(ctx, account, post)
+}
+
+func newTestPublisher() (*Publisher, *memoryStore, *observingConnector) {
+	mem := newMemoryStore()
+	connector := &observingConnector{FakeConnector: NewFakeConnector(), store: mem, statuses: map[string]string{}}
+	publisher := newPublisher(func(context.Context) store { return mem }, connector)
+	return publisher, mem, connector
+}
+
+func TestPublisherPublishesDuePosts(t *testing.T) {
+	publisher, store, connector := newTestPublisher()
+	now := time.Now()
+	store.add("draft", "fake-account", models.SocialPostDraft, time.Time{})
+	store.add("due", "fake-account", models.SocialPostScheduled, now.Add(-time.Minute))
+	store.add("later", "fake-account", models.SocialPostScheduled, now.Add(time.Hour))
+
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+
+	if status := connector.statuses["due"]; status != models.SocialPostPublishing {
+		t.Errorf("post was %q while being published, want %q", status, models.SocialPostPublishing)
+	}
+	due := store.get("due")
+	if due.Status != models.SocialPostPublished || due.ExternalID == nil || *due.ExternalID != "fake-1" {
+		t.Errorf("due post = %+v, want published with external id fake-1", due)
+	}
+	if status := store.get("draft").Status; status != models.SocialPostDraft {
+		t.Errorf("draft = %q, want it left alone", status)
+	}
+	if status := store.get("later").Status; status != models.SocialPostScheduled {
+		t.Errorf("post scheduled later = %q, want it left alone", status)
+	}
+	if len(connector.Published) != 1 {
+		t.Errorf("published %d posts, want 1", len(connector.Published))
+	}
+
+	// Once scheduled, the draft goes out on the next run
+	store.schedule("draft", now)
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+	if status := store.get("draft").Status; status != models.SocialPostPublished {
+		t.Errorf("scheduled draft = %q, want %q", status, models.SocialPostPublished)
+	}
+}
+
+func TestPublisherMarksFailedPosts(t *testing.T) {
+	publisher, store, connector := newTestPublisher()
+	past := time.Now().Add(-time.Minute)
+	store.add("rejected", "fake-account", models.SocialPostScheduled, past)
+	store.add("no-account", "missing-account", models.SocialPostScheduled, past)
+	store.add("no-connector", "gone-account", models.SocialPostScheduled, past)
+	connector.Err = errors.New("platform is down")
+
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+
+	for id, want := range map[string]string{
+		"rejected":     "platform is down",
+		"no-account":   "account missing-account not found",
+		"no-connector": "no connector for platform gone",
+	} {
+		post := store.get(id)
+		if post.Status != models.SocialPostFailed || post.LastError == nil || *post.LastError != want {
+			t.Errorf("post %s = %+v, want failed with %q", id, post, want)
+		}
+	}
+
+	// Failed posts are only published again once they are rescheduled
+	connector.Err = nil
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+	if status := store.get("rejected").Status; status != models.SocialPostFailed {
+		t.Errorf("failed post = %q, want it left alone", status)
+	}
+	store.schedule("rejected", time.Now())
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+	if status := store.get("rejected").Status; status != models.SocialPostPublished {
+		t.Errorf("rescheduled post = %q, want %q", status, models.SocialPostPublished)
+	}
+}
+
+func TestPublisherReclaimsExpiredLeases(t *testing.T) {
+	publisher, store, connector := newTestPublisher()
+	past := time.Now().Add(-time.Hour)
+	store.add("abandoned", "fake-account", models.SocialPostPublishing, past)
+	store.posts["abandoned"].claimedUntil = time.Now().Add(-time.Second)
+	store.add("in-flight", "fake-account", models.SocialPostPublishing, past)
+	store.posts["in-flight"].claimedUntil = time.Now().Add(time.Minute)
+
+	if err := publisher.publishDuePosts(context.Background()); err != nil {
+		t.Fatalf("publishDuePosts() = %v", err)
+	}
+
+	if status := store.get("abandoned").Status; status != models.SocialPostPublished {
+		t.Errorf("post with expired lease = %q, want %q", status, models.SocialPostPublished)
+	}
+	if status := store.get("in-flight").Status; status != models.SocialPostPublishing {
+		t.Errorf("post with running lease = %q, want it left alone", status)
+	}
+	if len(connector.Published) != 1 {
+		t.Errorf("published %d posts, want 1", len(connector.Published))
+	}
+}
@@ -0,0 +1,41 @@
+package social
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"api/internal/models"
+)
+
+// FakeConnector records published posts in memory instead of calling an
+// external platform. Setting Err makes every publish fail.
+type FakeConnector struct {
+	mu        sync.Mutex
+	Err       error
+	Published []models.SocialPost
+}
+
+func NewFakeConnector() *FakeConnector {
+	return &FakeConnector{}
+}
+
+func (f *FakeConnector) Platform() string {
+	return "fake"
+}
+
+func (f *FakeConnector) Validate(ctx context.Context, config map[string]string) error {
+	return nil
+}
+
+func (f *FakeConnector) Publish(ctx context.Context, account models.SocialAccount, post models.SocialPost) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return "", f.Err
+	}
+
+	f.Published = append(f.Published, post)
+	return fmt.Sprintf("fake-%d", len(f.Published)), nil
+}
@@ -0,0 +1,86 @@
+package social
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"api/internal/models"
+	"api/pkg/netguard"
+)
+
+// HTTPConnector posts a JSON document to the URL in the account config. It
+// covers platforms that accept incoming webhooks as well as custom
+// integrations. An optional "token" is sent as a bearer token.
+type HTTPConnector struct {
+	client *http.Client
+}
+
+func NewHTTPConnector() *HTTPConnector {
+	return &HTTPConnector{
+		// Account URLs are chosen by club members, connections to the
+		// internal network are refused
+		client: netguard.NewClient(15 * time.Second),
+	}
+}
+
+func (h *HTTPConnector) Platform() string {
+	return "webhook"
+}
+
+func (h *HTTPConnector) Validate(ctx context.Context, config map[string]string) error {
+	if err := netguard.CheckURL(ctx, config["url"]); err != nil {
+		return fmt.Errorf("config.url: %w", err)
+	}
+	return nil
+}
+
+type httpPostBody struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Image   string `json:"image,omitempty"`
+}
+
+type httpPostResponse struct {
+	ID string `json:"id"`
+}
+
+func (h *HTTPConnector) Publish(ctx context.Context, account models.SocialAccount, post models.SocialPost) (string, error) {
+	body, err := json.Marshal(httpPostBody{
+		ID:      post.ID,
+		Content: post.Content,
+		Image:   post.Image,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, account.Config["url"], bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := account.Config["token"]; token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	// The response body is optional; an "id" field is kept as external id
+	var response httpPostResponse
+	json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&response)
+
+	return response.ID, nil
+}
@@ -0,0 +1,79 @@
+package trash
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"api/internal/repository"
+	"api/pkg/logging"
+)
+
+// Purger permanently deletes clubs, events and posts that have been in the
+// trash for longer than the retention.
+type Purger struct {
+	db        *sql.DB
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewPurger(db *sql.DB, retention, interval time.Duration) *Purger {
+	return &Purger{
+		db:        db,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges expired items every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.purge(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to purge trash", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) error {
+	before := time.Now().Add(-p.retention)
+
+	var posts, events, clubs int64
+	err := repository.RunInTx(ctx, p.db, func(tx *repository.UnitOfWork) error {
+		var err error
+		if posts, err = tx.Posts().PurgeDeletedPosts(before); err != nil {
+			return err
+		}
+		if events, err = tx.Events().PurgeDeletedEvents(before); err != nil {
+			return err
+		}
+
+		clubIDs, err := tx.Clubs().ListDeletedClubIDs(before)
+		if err != nil {
+			return err
+		}
+		for _, clubID := range clubIDs {
+			if err := tx.Clubs().PurgeClub(clubID); err != nil {
+				return err
+			}
+		}
+		clubs = int64(len(clubIDs))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if posts+events+clubs > 0 {
+		logging.FromContext(ctx).Info("purged trash", "posts", posts, "events", events, "clubs", clubs)
+	}
+	return nil
+}
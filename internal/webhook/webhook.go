@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"api/internal/bus"
+	"api/internal/repository"
+	db "api/pkg/database"
+	"api/pkg/logging"
+	"api/pkg/netguard"
+)
+
+const (
+	pollInterval = 5 * time.Second
+	batchSize    = 50
+	leaseTime    = time.Minute
+
+	// Retries back off exponentially from baseBackoff up to maxBackoff.
+	// After maxAttempts the delivery is marked as failed and can only be
+	// redelivered by hand.
+	maxAttempts = 8
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 6 * time.Hour
+)
+
+// Topics lists the bus topics clubs can subscribe their webhooks to.
+var Topics = map[bus.Topic]bool{
+	bus.EventCreated:      true,
+	bus.EventUpdated:      true,
+	bus.EventCancelled:    true,
+	bus.EventDeleted:      true,
+	bus.ClubMemberAdded:   true,
+	bus.ClubMemberRemoved: true,
+	bus.FeedPostCreated:   true,
+}
+
+// Payload is the JSON body sent to the webhook URL.
+type Payload struct {
+	Topic      bus.Topic `json:"topic"`
+	ClubID     string    `json:"club_id"`
+	ActorID    string    `json:"actor_id,omitempty"`
+	UserID     string    `json:"user_id,omitempty"`
+	Data       any       `json:"data,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Service queues deliveries for bus messages and sends them in the
+// background.
+type Service struct {
+	db     *sql.DB
+	client *http.Client
+}
+
+func NewService(db *sql.DB) *Service {
+	// Webhook URLs are chosen by club admins, connections to the internal
+	// network are refused even when a public host name resolves to it
+	return &Service{
+		db:     db,
+		client: netguard.NewClient(10 * time.Second),
+	}
+}
+
+// Handle is a bus handler that stores one pending delivery per subscribed
+// webhook of the message's club.
+func (s *Service) Handle(ctx context.Context, msg bus.Message) {
+	if !Topics[msg.Topic] || msg.ClubID == "" {
+		return
+	}
+
+	logger := logging.FromContext(ctx).With("topic", msg.Topic, "club_id", msg.ClubID)
+	webhookRepository := repository.NewWebhookRepository(db.WithContext(ctx, s.db))
+	webhookIDs, err := webhookRepository.GetSubscribedWebhookIDs(msg.ClubID, string(msg.Topic))
+	if err != nil {
+		logger.Error("failed to get webhooks of club", "error", err)
+		return
+	}
+	if len(webhookIDs) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(Payload{
+		Topic:      msg.Topic,
+		ClubID:     msg.ClubID,
+		ActorID:    msg.ActorID,
+		UserID:     msg.UserID,
+		Data:       msg.Data,
+		OccurredAt: msg.OccurredAt,
+	})
+	if err != nil {
+		logger.Error("failed to encode webhook payload", "error", err)
+		return
+	}
+
+	for _, webhookID := range webhookIDs {
+		if _, err := webhookRepository.CreateDelivery(webhookID, string(msg.Topic), payload); err != nil {
+			logger.Error("failed to queue webhook delivery", "webhook_id", webhookID, "error", err)
+		}
+	}
+}
+
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.sendDueDeliveries(ctx); err != nil {
+			logging.FromContext(ctx).Error("failed to send webhook deliveries", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) sendDueDeliveries(ctx context.Context) error {
+	// Outcomes of deliveries already sent are recorded even while shutting
+	// down, otherwise they would be sent again once their lease expires.
+	webhookRepository := repository.NewWebhookRepository(db.WithContext(context.WithoutCancel(ctx), s.db))
+
+	for {
+		deliveries, err := webhookRepository.ClaimDueDeliveries(batchSize, leaseTime)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			statusCode, err := s.send(ctx, delivery.ID, delivery.Topic, delivery.URL, delivery.Secret, delivery.Payload)
+			if err == nil {
+				err = webhookRepository.MarkDeliverySucceeded(delivery.ID, statusCode)
+			} else {
+				var nextAttemptAt *time.Time
+				if attempts := delivery.Attempts + 1; attempts < maxAttempts {
+					next := time.Now().Add(Backoff(attempts))
+					nextAttemptAt = &next
+				}
+				err = webhookRepository.MarkDeliveryAttemptFailed(delivery.ID, statusCode, err.Error(), nextAttemptAt)
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+			}
+		}
+
+		if len(deliveries) < batchSize || ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+func (s *Service) send(ctx context.Context, deliveryID, topic, url, secret string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Community-Portal-Webhooks/1.0")
+	req.Header.Set(TopicHeader, topic)
+	req.Header.Set(DeliveryHeader, deliveryID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the delay before the next attempt after the given number
+// of failed attempts.
+func Backoff(attempts int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS mails;
+DROP TABLE IF EXISTS attended_events;
+DROP TABLE IF EXISTS likes;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS gallery_posts;
+DROP TABLE IF EXISTS feed_posts;
+DROP TABLE IF EXISTS club_roles;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS clubs;
+DROP TABLE IF EXISTS users;
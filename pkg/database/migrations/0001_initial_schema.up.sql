@@ -0,0 +1,104 @@
+CREATE TABLE IF NOT EXISTS users  (
+   id  varchar PRIMARY KEY,
+   school_id  varchar,
+   first_name  varchar,
+   last_name  varchar,
+   email  varchar,
+   telephone_number  varchar,
+   email_preferences  varchar,
+   marketing_preferences  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS clubs  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   name  varchar,
+   description  text,
+   email  varchar,
+   member_count  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS events  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID REFERENCES clubs ( id ),
+   title  varchar,
+   description  varchar,
+   start_date  timestamp,
+   end_date timestamp,
+   tags  varchar,
+   location  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS club_roles  (
+   user_id  varchar REFERENCES users ( id ),
+   club_id  UUID REFERENCES clubs ( id ),
+   role  varchar,
+   created_at  timestamp,
+   updated_at  timestamp,
+  PRIMARY KEY ( user_id ,  club_id )
+);
+
+CREATE TABLE IF NOT EXISTS feed_posts  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   author_club_id  UUID REFERENCES clubs ( id ),
+   author_user_id  varchar REFERENCES users ( id ),
+   iamge  text,
+   description  text,
+   like_count  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS gallery_posts  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   author_club_id  UUID REFERENCES clubs ( id ),
+   author_user_id  varchar REFERENCES users ( id ),
+   iamge  text,
+   description  text,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS comments  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   author_user_id  varchar REFERENCES users ( id ),
+   post_id  UUID REFERENCES feed_posts ( id ),
+   comment_id  UUID,
+   comment  text,
+   like_count  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS likes  (
+   user_id  varchar REFERENCES users ( id ),
+   post_id  UUID REFERENCES feed_posts ( id ),
+   created_at  timestamp,
+   updated_at  timestamp,
+  PRIMARY KEY ( user_id ,  post_id )
+);
+
+CREATE TABLE IF NOT EXISTS attended_events  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   user_id  varchar REFERENCES users ( id ),
+   event_id  UUID REFERENCES events ( id ),
+   situation  varchar,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS mails  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   author_club_id  UUID REFERENCES clubs ( id ),
+   author_user_id  varchar REFERENCES users ( id ),
+   subject  varchar,
+   content  text,
+   recipients  text,
+   created_at  timestamp,
+   updated_at  timestamp
+);
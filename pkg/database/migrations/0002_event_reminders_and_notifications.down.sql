@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS event_reminders;
+ALTER TABLE events DROP COLUMN IF EXISTS status;
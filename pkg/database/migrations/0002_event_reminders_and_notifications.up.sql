@@ -0,0 +1,28 @@
+ALTER TABLE events ADD COLUMN IF NOT EXISTS status varchar DEFAULT 'active';
+
+CREATE TABLE IF NOT EXISTS event_reminders  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   event_id  UUID REFERENCES events ( id ) ON DELETE CASCADE,
+   offset_minutes  integer,
+   remind_at  timestamp,
+   sent_at  timestamp,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS notifications  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   user_id  varchar REFERENCES users ( id ),
+   club_id  UUID REFERENCES clubs ( id ),
+   type  varchar,
+   title  varchar,
+   body  text,
+   data  jsonb,
+   read_at  timestamp,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE INDEX IF NOT EXISTS event_reminders_due_idx ON event_reminders ( remind_at ) WHERE sent_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS notifications_user_idx ON notifications ( user_id, created_at DESC );
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS webhooks  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID REFERENCES clubs ( id ),
+   url  varchar,
+   secret  varchar,
+   topics  text[],
+   active  boolean DEFAULT true,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   webhook_id  UUID REFERENCES webhooks ( id ) ON DELETE CASCADE,
+   topic  varchar,
+   payload  jsonb,
+   status  varchar,
+   attempts  integer DEFAULT 0,
+   next_attempt_at  timestamp,
+   last_status_code  integer,
+   last_error  text,
+   delivered_at  timestamp,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries ( next_attempt_at ) WHERE status = 'pending';
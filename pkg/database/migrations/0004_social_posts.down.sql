@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS social_posts;
+DROP TABLE IF EXISTS social_accounts;
@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS social_accounts  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID REFERENCES clubs ( id ),
+   platform  varchar,
+   name  varchar,
+   config  jsonb,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE TABLE IF NOT EXISTS social_posts  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID REFERENCES clubs ( id ),
+   account_id  UUID REFERENCES social_accounts ( id ) ON DELETE CASCADE,
+   author_user_id  varchar REFERENCES users ( id ),
+   event_id  UUID REFERENCES events ( id ) ON DELETE SET NULL,
+   content  text,
+   image  text,
+   status  varchar,
+   scheduled_at  timestamp,
+   published_at  timestamp,
+   external_id  varchar,
+   last_error  text,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+CREATE INDEX IF NOT EXISTS social_posts_due_idx ON social_posts ( scheduled_at ) WHERE status = 'scheduled';
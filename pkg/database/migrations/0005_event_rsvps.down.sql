@@ -0,0 +1 @@
+DROP INDEX IF EXISTS attended_events_user_event_key;
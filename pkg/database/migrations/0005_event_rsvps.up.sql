@@ -0,0 +1,8 @@
+-- Keep only the latest answer of a user per event before making it unique
+DELETE FROM attended_events a
+USING attended_events b
+WHERE a.user_id = b.user_id
+   AND a.event_id = b.event_id
+   AND (COALESCE(a.updated_at, a.created_at, 'epoch'), a.id) < (COALESCE(b.updated_at, b.created_at, 'epoch'), b.id);
+
+CREATE UNIQUE INDEX IF NOT EXISTS attended_events_user_event_key ON attended_events ( user_id, event_id );
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS rate_limit_buckets  (
+   key  varchar PRIMARY KEY,
+   tokens  double precision NOT NULL,
+   allowed  boolean NOT NULL,
+   updated_at  timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS rate_limit_buckets_updated_at_idx ON rate_limit_buckets ( updated_at );
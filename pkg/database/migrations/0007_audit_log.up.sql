@@ -0,0 +1,28 @@
+-- club_id has no foreign key so the entries outlive deleted clubs
+CREATE TABLE IF NOT EXISTS audit_log  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID,
+   actor_user_id  varchar,
+   action  varchar NOT NULL,
+   target_type  varchar NOT NULL,
+   target_id  varchar,
+   before  jsonb,
+   after  jsonb,
+   changes  jsonb,
+   ip  varchar,
+   request_id  varchar,
+   created_at  timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_club_created_idx ON audit_log ( club_id, created_at DESC );
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+   RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+CREATE TRIGGER audit_log_append_only
+   BEFORE UPDATE OR DELETE ON audit_log
+   FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS feed_posts_deleted_at_idx;
+DROP INDEX IF EXISTS events_deleted_at_idx;
+DROP INDEX IF EXISTS clubs_deleted_at_idx;
+ALTER TABLE feed_posts DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE events DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE clubs DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,8 @@
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+ALTER TABLE feed_posts ADD COLUMN IF NOT EXISTS deleted_at timestamp;
+
+-- Only the trash listings and the purge job look at deleted rows
+CREATE INDEX IF NOT EXISTS clubs_deleted_at_idx ON clubs ( deleted_at ) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS events_deleted_at_idx ON events ( club_id, deleted_at ) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS feed_posts_deleted_at_idx ON feed_posts ( author_club_id, deleted_at ) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS club_ownership_transfers  (
+   id  UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+   club_id  UUID REFERENCES clubs ( id ) ON DELETE CASCADE,
+   from_user_id  varchar REFERENCES users ( id ),
+   to_user_id  varchar REFERENCES users ( id ),
+   stay_owner  boolean NOT NULL DEFAULT false,
+   status  varchar NOT NULL,
+   expires_at  timestamp NOT NULL,
+   created_at  timestamp,
+   updated_at  timestamp
+);
+
+-- A club has at most one transfer waiting for an answer
+CREATE UNIQUE INDEX IF NOT EXISTS club_ownership_transfers_pending_key ON club_ownership_transfers ( club_id ) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS club_ownership_transfers_to_user_idx ON club_ownership_transfers ( to_user_id ) WHERE status = 'pending';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS club_roles_valid_until_idx;
+
+ALTER TABLE club_roles DROP COLUMN IF EXISTS expiry_notified_at;
+ALTER TABLE club_roles DROP COLUMN IF EXISTS granted_by;
+ALTER TABLE club_roles DROP COLUMN IF EXISTS valid_until;
+ALTER TABLE club_roles DROP COLUMN IF EXISTS valid_from;
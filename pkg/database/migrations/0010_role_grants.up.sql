@@ -0,0 +1,9 @@
+-- Roles can be granted for a limited time. granted_by is the member who
+-- delegated the role, expiry_notified_at is set once the member was told the
+-- grant is about to expire.
+ALTER TABLE club_roles ADD COLUMN IF NOT EXISTS valid_from timestamp;
+ALTER TABLE club_roles ADD COLUMN IF NOT EXISTS valid_until timestamp;
+ALTER TABLE club_roles ADD COLUMN IF NOT EXISTS granted_by varchar REFERENCES users ( id );
+ALTER TABLE club_roles ADD COLUMN IF NOT EXISTS expiry_notified_at timestamp;
+
+CREATE INDEX IF NOT EXISTS club_roles_valid_until_idx ON club_roles ( valid_until ) WHERE valid_until IS NOT NULL;
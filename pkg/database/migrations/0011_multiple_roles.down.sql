@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS club_roles_club_user_idx;
+
+-- Only the highest ranked role of every member is kept
+DELETE FROM club_roles cr
+USING club_roles other
+WHERE other.user_id = cr.user_id AND other.club_id = cr.club_id
+   AND (CASE other.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 3 WHEN 'member' THEN 1 ELSE 2 END, other.role)
+     > (CASE cr.role WHEN 'owner' THEN 4 WHEN 'admin' THEN 3 WHEN 'member' THEN 1 ELSE 2 END, cr.role);
+
+ALTER TABLE club_roles DROP CONSTRAINT IF EXISTS club_roles_pkey;
+ALTER TABLE club_roles ADD CONSTRAINT club_roles_pkey PRIMARY KEY ( user_id, club_id );
+ALTER TABLE club_roles ALTER COLUMN role DROP NOT NULL;
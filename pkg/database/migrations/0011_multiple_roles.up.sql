@@ -0,0 +1,9 @@
+-- A member holds one row per role. The existing rows already are the single
+-- role of their member, so they are kept as they are.
+UPDATE club_roles SET role = 'member' WHERE role IS NULL;
+ALTER TABLE club_roles ALTER COLUMN role SET NOT NULL;
+
+ALTER TABLE club_roles DROP CONSTRAINT IF EXISTS club_roles_pkey;
+ALTER TABLE club_roles ADD CONSTRAINT club_roles_pkey PRIMARY KEY ( user_id, club_id, role );
+
+CREATE INDEX IF NOT EXISTS club_roles_club_user_idx ON club_roles ( club_id, user_id );
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS users_platform_role_idx;
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE clubs DROP COLUMN IF EXISTS suspended_at;
+
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP COLUMN IF EXISTS platform_role;
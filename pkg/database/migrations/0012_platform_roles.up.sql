@@ -0,0 +1,9 @@
+-- Platform roles apply across all clubs, most users have none
+ALTER TABLE users ADD COLUMN IF NOT EXISTS platform_role varchar;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at timestamp;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason varchar;
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS suspended_at timestamp;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS suspension_reason varchar;
+
+CREATE INDEX IF NOT EXISTS users_platform_role_idx ON users ( platform_role ) WHERE platform_role IS NOT NULL;
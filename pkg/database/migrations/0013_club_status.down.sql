@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS clubs_status_idx;
+
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS suspended_at timestamp;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS suspension_reason varchar;
+UPDATE clubs
+SET suspended_at = COALESCE(status_changed_at, now()), suspension_reason = status_reason
+WHERE status = 'suspended';
+
+ALTER TABLE clubs DROP COLUMN IF EXISTS verified_at;
+ALTER TABLE clubs DROP COLUMN IF EXISTS status_changed_at;
+ALTER TABLE clubs DROP COLUMN IF EXISTS status_changed_by;
+ALTER TABLE clubs DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE clubs DROP COLUMN IF EXISTS status;
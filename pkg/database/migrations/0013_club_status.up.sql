@@ -0,0 +1,16 @@
+-- Existing clubs are approved already, new ones wait for a moderator
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS status varchar NOT NULL DEFAULT 'active';
+ALTER TABLE clubs ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS status_reason varchar;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS status_changed_by varchar REFERENCES users ( id );
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS status_changed_at timestamp;
+ALTER TABLE clubs ADD COLUMN IF NOT EXISTS verified_at timestamp;
+
+-- Suspension is one of the statuses now
+UPDATE clubs
+SET status = 'suspended', status_reason = suspension_reason, status_changed_at = suspended_at
+WHERE suspended_at IS NOT NULL;
+ALTER TABLE clubs DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE clubs DROP COLUMN IF EXISTS suspended_at;
+
+CREATE INDEX IF NOT EXISTS clubs_status_idx ON clubs ( status ) WHERE deleted_at IS NULL;
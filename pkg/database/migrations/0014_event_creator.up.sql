@@ -0,0 +1,3 @@
+-- The member who created an event, NULL for events created before it was
+-- recorded. Authorization policies let creators manage their own events.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS created_by varchar REFERENCES users ( id );
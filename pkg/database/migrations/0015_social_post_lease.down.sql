@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS social_posts_claimed_idx;
+ALTER TABLE social_posts DROP COLUMN IF EXISTS claimed_until;
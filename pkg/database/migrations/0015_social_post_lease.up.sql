@@ -0,0 +1,8 @@
+-- Posts being published are leased, so posts claimed by an instance that
+-- crashed before recording the outcome are claimed again once it expires
+ALTER TABLE social_posts ADD COLUMN IF NOT EXISTS claimed_until timestamp;
+
+-- Posts stuck in publishing before the lease existed are claimed again
+UPDATE social_posts SET claimed_until = updated_at WHERE status = 'publishing' AND claimed_until IS NULL;
+
+CREATE INDEX IF NOT EXISTS social_posts_claimed_idx ON social_posts ( claimed_until ) WHERE status = 'publishing';
@@ -0,0 +1 @@
+ALTER TABLE event_reminders DROP COLUMN IF EXISTS claimed_until;
@@ -0,0 +1,4 @@
+-- Reminders being sent are leased and only marked as sent once dispatched,
+-- so reminders claimed by an instance that crashed are claimed again once
+-- the lease expires
+ALTER TABLE event_reminders ADD COLUMN IF NOT EXISTS claimed_until timestamp;
@@ -1,10 +1,10 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"os"
-	"strconv"
+	"log/slog"
 	"time"
 
 	_ "github.com/lib/pq"
@@ -17,48 +17,72 @@ type Config struct {
 	Password string
 	DBName   string
 	SSLMode  string
-}
 
-func SetupDb() (*sql.DB, error) {
-	config := Config{
-		Host:     os.Getenv("DB_HOST"),
-		Port:     5432, // Default to 5432 if DB_PORT is not set
-		User:     os.Getenv("DB_USER"),
-		Password: os.Getenv("DB_PASSWORD"),
-		DBName:   os.Getenv("DB_NAME"),
-		SSLMode:  os.Getenv("DB_SLL_MODE"),
-	}
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	// How long SetupDb keeps retrying until the database accepts connections
+	ConnectTimeout time.Duration
+}
 
-	if portStr := os.Getenv("DB_PORT"); portStr != "" {
-		if port, err := strconv.Atoi(portStr); err == nil {
-			config.Port = port
-		} else {
-			fmt.Printf("Warning: Invalid DB_PORT value '%s', using default 5432\n", portStr)
-		}
-	}
+const (
+	initialRetryDelay = 500 * time.Millisecond
+	maxRetryDelay     = 10 * time.Second
+)
 
-	fmt.Printf("Attempting to connect to database with config: %+v\n", config)
+func (c Config) ConnectionString() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		c.Host, c.Port, c.User, c.Password, c.DBName, c.SSLMode)
+}
 
-	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
-		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
+func SetupDb(config Config) (*sql.DB, error) {
+	slog.Info("connecting to database", "database", config.DBName, "host", config.Host, "port", config.Port, "user", config.User)
 
-	sqlDB, err := sql.Open("postgres", connStr)
+	sqlDB, err := sql.Open("postgres", config.ConnectionString())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
-	sqlDB.SetMaxOpenConns(25)
-	sqlDB.SetMaxIdleConns(25)
-	sqlDB.SetConnMaxLifetime(5 * time.Minute)
+	sqlDB.SetMaxOpenConns(config.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(config.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(config.ConnMaxLifetime)
 
-	// Verify the connection
-	if err := sqlDB.Ping(); err != nil {
+	// Verify the connection, retrying while the database is starting up
+	if err := waitForDb(sqlDB, config.ConnectTimeout); err != nil {
 		sqlDB.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	fmt.Println("Successfully connected to the database")
+	slog.Info("connected to database")
 
 	return sqlDB, nil
 
 }
+
+// Pings the database with exponential backoff until it answers or the
+// timeout is reached.
+func waitForDb(sqlDB *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	delay := initialRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := sqlDB.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		slog.Warn("database not ready", "attempt", attempt, "retry_in", delay.String(), "error", err)
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
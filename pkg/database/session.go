@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"api/pkg/logging"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("api/pkg/database")
+
+// Conn is the handle the repositories run their statements on. It is
+// implemented by *sql.DB, *sql.Tx and *Session.
+type Conn interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+type contextConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Session binds a *sql.DB or *sql.Tx to a context. Statements are cancelled
+// with the context, logged with the logger it carries and traced as child
+// spans of the span it carries.
+type Session struct {
+	ctx  context.Context
+	conn contextConn
+}
+
+func WithContext(ctx context.Context, conn contextConn) *Session {
+	return &Session{
+		ctx:  ctx,
+		conn: conn,
+	}
+}
+
+func (s *Session) Context() context.Context {
+	return s.ctx
+}
+
+func (s *Session) Exec(query string, args ...any) (sql.Result, error) {
+	ctx, done := s.start(statementName())
+	result, err := s.conn.ExecContext(ctx, query, args...)
+	done(err)
+	return result, err
+}
+
+// The span of Query ends once the query returned its first rows, reading
+// the remaining rows is not part of it.
+func (s *Session) Query(query string, args ...any) (*sql.Rows, error) {
+	ctx, done := s.start(statementName())
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (s *Session) QueryRow(query string, args ...any) *sql.Row {
+	ctx, done := s.start(statementName())
+	row := s.conn.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+// start opens the span of a statement. The returned function ends it and
+// logs the statement.
+func (s *Session) start(statement string) (context.Context, func(err error)) {
+	start := time.Now()
+	ctx, span := tracer.Start(s.ctx, statement,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", statement),
+		),
+	)
+
+	return ctx, func(err error) {
+		logger := logging.FromContext(s.ctx)
+		duration := time.Since(start)
+
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			logger.Error("query failed", "statement", statement, "duration_ms", duration.Milliseconds(), "error", err)
+			return
+		}
+		span.End()
+		logger.Debug("query", "statement", statement, "duration_ms", duration.Milliseconds())
+	}
+}
+
+// Names a statement after the repository method that ran it, e.g.
+// "ClubRepository.GetClubByID".
+func statementName() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return "unknown"
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return "unknown"
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	return name
+}
@@ -0,0 +1,21 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+// InTx runs fn in a transaction. The transaction is committed when fn
+// returns nil and rolled back otherwise.
+func InTx(ctx context.Context, sqlDB *sql.DB, fn func(conn Conn) error) error {
+	tx, err := sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(WithContext(ctx, tx)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
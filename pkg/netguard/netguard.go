@@ -0,0 +1,116 @@
+// Package netguard keeps outgoing requests to URLs chosen by users, such as
+// webhooks, away from the internal network of the server.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+var ErrBlocked = errors.New("address is not publicly routable")
+
+// Ranges that are neither loopback, private nor link-local but still do not
+// lead to the public internet.
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// Blocked reports whether requests to ip must be refused: loopback, private,
+// link-local, unspecified and multicast addresses.
+func Blocked(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Control is a net.Dialer Control function refusing connections to blocked
+// addresses. It runs after name resolution, so host names resolving to an
+// internal address are caught as well.
+func Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%s: %w", address, ErrBlocked)
+	}
+	if Blocked(addrPort.Addr()) {
+		return fmt.Errorf("%s: %w", addrPort.Addr(), ErrBlocked)
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client that only connects to public addresses,
+// redirects included. Proxies from the environment are ignored as they
+// would connect on behalf of the client.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: Control,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkURL(req.URL)
+		},
+	}
+}
+
+// CheckURL validates a URL before it is stored: it must be http or https and
+// its host must not resolve to a blocked address.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return errors.New("invalid url")
+	}
+	if err := checkURL(u); err != nil {
+		return err
+	}
+
+	if _, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return fmt.Errorf("host %s cannot be resolved", u.Hostname())
+	}
+	for _, ip := range ips {
+		if Blocked(ip) {
+			return fmt.Errorf("host %s: %w", u.Hostname(), ErrBlocked)
+		}
+	}
+	return nil
+}
+
+// checkURL checks the scheme of u and its host when it is an IP address.
+// Host names are checked when connecting.
+func checkURL(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("url must be an http or https url")
+	}
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil && Blocked(ip) {
+		return fmt.Errorf("host %s: %w", ip, ErrBlocked)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package netguard
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+)
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"fd00::1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"224.0.0.1", true},
+		{"ff02::1", true},
+		{"100.64.0.1", true},
+		{"::ffff:127.0.0.1", true},
+		{"::ffff:10.0.0.1", true},
+		{"93.184.216.34", false},
+		{"1.1.1.1", false},
+		{"2606:4700:4700::1111", false},
+		{"::ffff:93.184.216.34", false},
+	}
+	for _, tt := range tests {
+		if got := Blocked(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("Blocked(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestControl(t *testing.T) {
+	if err := Control("tcp4", "169.254.169.254:80", nil); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Control() = %v, want ErrBlocked", err)
+	}
+	if err := Control("tcp6", "[::1]:9090", nil); !errors.Is(err, ErrBlocked) {
+		t.Errorf("Control() = %v, want ErrBlocked", err)
+	}
+	if err := Control("tcp4", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("Control() = %v, want nil", err)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, rawURL := range []string{
+		"ftp://example.com/hook",
+		"https://",
+		"http://127.0.0.1:9090/metrics",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/",
+		"http://10.0.0.5/hook",
+		"http://localhost:8080/hook",
+	} {
+		if err := CheckURL(context.Background(), rawURL); err == nil {
+			t.Errorf("CheckURL(%q) = nil, want an error", rawURL)
+		}
+	}
+
+	if err := CheckURL(context.Background(), "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("CheckURL() = %v, want nil", err)
+	}
+}
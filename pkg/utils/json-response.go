@@ -1,15 +1,23 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
+	"strings"
 )
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// Writes an error response. The message is also handed to the access log
+// when w is wrapped by the request logging middleware.
 func JSONError(w http.ResponseWriter, status int, message string) {
+	if recorder, ok := w.(interface{ SetError(string) }); ok {
+		recorder.SetError(message)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
@@ -20,3 +28,43 @@ func JSONResponse(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(data)
 }
+
+// Writes data like JSONResponse along with an ETag derived from it. Clients
+// sending that ETag back in If-None-Match get a 304 without a body until
+// data changes. The response is private to the caller and revalidated on
+// every use.
+func JSONResponseWithETag(w http.ResponseWriter, r *http.Request, data interface{}) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		JSONError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+	w.Header().Add("Vary", "Authorization")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(body, '\n'))
+}
+
+// Reports whether an If-None-Match header lists etag. Weak comparison is
+// used, as RFC 9110 asks for with If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
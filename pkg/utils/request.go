@@ -2,7 +2,12 @@ package utils
 
 import (
 	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
 )
 
 // Extracts token claims from the context
@@ -25,3 +30,32 @@ func GetUserIDFromClaims(claims map[string]any) (string, bool) {
 func DecodeRequestBody(r *http.Request, payload interface{}) error {
 	return json.NewDecoder(r.Body).Decode(payload)
 }
+
+// Reads an integer query parameter, falling back to def when it is missing
+func GetQueryInt(r *http.Request, key string, def int) (int, error) {
+	value := r.URL.Query().Get(key)
+	if value == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		return 0, fmt.Errorf("invalid %s query parameter", key)
+	}
+	return i, nil
+}
+
+// Returns the IP address of the client that sent the request
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Reports whether id is a UUID in its canonical form, as stored by the
+// database. IDs from the client are checked before they reach a query, where
+// they would fail with a driver error.
+func IsUUID(id string) bool {
+	return len(id) == 36 && uuid.Validate(id) == nil
+}
@@ -12,8 +12,8 @@ if ! pg_isready > /dev/null 2>&1; then
     exit 1
 fi
 
-echo "📦 Veritabanı oluşturuluyor..."
-if psql -U community -d community -f dbsetup.sql; then
+echo "📦 Migration'lar uygulanıyor..."
+if (cd "$(dirname "$0")/.." && go run ./cmd/server migrate up); then
     echo -e "${GREEN}✅ Veritabanı başarıyla kuruldu!${NC}"
 else
     echo -e "${RED}❌ Veritabanı kurulumu sırasında bir hata oluştu!${NC}"
@@ -27,4 +27,4 @@ else
     exit 1
 fi
 
-echo -e "${GREEN}🎉 Kurulum tamamlandı!${NC}" 
\ No newline at end of file
+echo -e "${GREEN}🎉 Kurulum tamamlandı!${NC}" 